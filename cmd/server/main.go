@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/lalith-99/echostream/internal/api"
 	"github.com/lalith-99/echostream/internal/config"
 	"github.com/lalith-99/echostream/internal/db"
@@ -19,10 +20,24 @@ import (
 	redisclient "github.com/lalith-99/echostream/internal/redis"
 	"github.com/lalith-99/echostream/internal/repository/postgres"
 	"github.com/lalith-99/echostream/internal/service"
+	"github.com/lalith-99/echostream/internal/storage"
 	"github.com/lalith-99/echostream/internal/websocket"
 	"go.uber.org/zap"
 )
 
+// eventBridge feeds Redis events to the local hub: redis.PubSub or, with
+// EVENT_TRANSPORT=streams, redis.Streams.
+type eventBridge interface {
+	Subscribe(channelID uuid.UUID)
+	Unsubscribe(channelID uuid.UUID)
+	SubscribeUser(userID uuid.UUID)
+	UnsubscribeUser(userID uuid.UUID)
+	SubscribeTenant(tenantID uuid.UUID)
+	UnsubscribeTenant(tenantID uuid.UUID)
+	Listen(ctx context.Context)
+	Close()
+}
+
 func main() {
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -55,10 +70,19 @@ func run() error {
 	}
 	defer rc.Close()
 
-	// WebSocket hub + Redis pub/sub bridge
+	// WebSocket hub + Redis bridge (pub/sub or streams, see EVENT_TRANSPORT)
 	hub := websocket.NewHub(logger)
-	pubsub := redisclient.NewPubSub(rc, hub, logger)
+	var pubsub eventBridge
+	if cfg.EventTransport == "streams" {
+		rc.UseStreams(cfg.StreamMaxLen)
+		pubsub = redisclient.NewStreams(rc, hub, cfg.NodeID, logger)
+	} else {
+		pubsub = redisclient.NewPubSub(rc, hub, logger)
+	}
+	logger.Info("event transport", zap.String("mode", cfg.EventTransport), zap.String("node_id", cfg.NodeID))
 	hub.SetChannelCallbacks(pubsub.Subscribe, pubsub.Unsubscribe)
+	hub.SetUserCallbacks(pubsub.SubscribeUser, pubsub.UnsubscribeUser)
+	hub.SetTenantCallbacks(pubsub.SubscribeTenant, pubsub.UnsubscribeTenant)
 
 	// Presence tracker — marks users online/offline in Redis
 	tracker := presence.NewTracker(rc.RDB(), logger)
@@ -77,21 +101,67 @@ func run() error {
 	channelRepo := postgres.NewChannelStore(pool)
 	membershipRepo := postgres.NewMembershipStore(pool)
 	messageRepo := postgres.NewMessageStore(pool)
+	reactionRepo := postgres.NewReactionStore(pool)
+	pinRepo := postgres.NewPinStore(pool)
+	scheduledRepo := postgres.NewScheduledMessageStore(pool)
 	userRepo := postgres.NewUserStore(pool)
 	signupRepo := postgres.NewSignupStore(pool)
+	tenantRepo := postgres.NewTenantStore(pool)
+	fileRepo := postgres.NewFileStore(pool)
+	outboxRepo := postgres.NewOutboxStore(pool)
+
+	// Blob storage for uploads
+	blobs, err := storage.NewLocalStore(cfg.StorageDir)
+	if err != nil {
+		return fmt.Errorf("open file storage: %w", err)
+	}
 
 	// Services (business logic layer)
-	messageSvc := service.NewMessageService(messageRepo, membershipRepo, rc, logger)
+	relay := service.NewOutboxRelay(outboxRepo, rc, logger)
+	messageSvc := service.NewMessageService(messageRepo, reactionRepo, membershipRepo, channelRepo, pinRepo, fileRepo, tracker, rc, relay, logger)
+	scheduleSvc := service.NewScheduleService(scheduledRepo, messageSvc, logger)
+	membershipSvc := service.NewMembershipService(membershipRepo, channelRepo, rc, rc, logger)
+	channelSvc := service.NewChannelService(channelRepo, membershipRepo, rc, rc, logger)
+	fileSvc := service.NewFileService(fileRepo, tenantRepo, membershipRepo, blobs, cfg.MaxUploadBytes, logger)
+	defer fileSvc.Wait() // let thumbnail jobs finish before the pool closes
 
 	// Handlers (thin HTTP adapters)
-	channelHandler := api.NewChannelHandler(channelRepo, membershipRepo, logger)
-	membershipHandler := api.NewMembershipHandler(membershipRepo, channelRepo, logger)
-	messageHandler := api.NewMessageHandler(messageSvc, logger)
+	channelHandler := api.NewChannelHandler(channelRepo, membershipRepo, channelSvc, logger)
+	membershipHandler := api.NewMembershipHandler(membershipRepo, channelRepo, membershipSvc, logger)
+	messageHandler := api.NewMessageHandler(messageSvc, scheduleSvc, logger)
+	fileHandler := api.NewFileHandler(fileSvc, logger)
 	userHandler := api.NewUserHandler(userRepo, logger)
+	dmHandler := api.NewDMHandler(channelRepo, userRepo, membershipSvc, logger)
 	authHandler := api.NewAuthHandler(userRepo, signupRepo, cfg.JWTSecret, logger)
-	wsHandler := api.NewWSHandler(hub, membershipRepo, cfg.JWTSecret, logger)
+	wsHandler := api.NewWSHandler(hub, membershipRepo, messageSvc, cfg.JWTSecret, logger)
 	presenceHandler := api.NewPresenceHandler(channelRepo, membershipRepo, tracker, logger)
 
+	// Scheduled message delivery. Stopped (and waited for) before the
+	// database pool closes.
+	schedCtx, schedCancel := context.WithCancel(context.Background())
+	schedDone := make(chan struct{})
+	go func() {
+		defer close(schedDone)
+		runScheduler(schedCtx, scheduleSvc, logger)
+	}()
+	defer func() {
+		schedCancel()
+		<-schedDone
+	}()
+
+	// Outbox relay: publishes message events committed with their
+	// messages. Stopped before the pool and the Redis client close.
+	relayCtx, relayCancel := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		runOutboxRelay(relayCtx, relay, logger)
+	}()
+	defer func() {
+		relayCancel()
+		<-relayDone
+	}()
+
 	srv := gin.New()
 	srv.Use(gin.Logger(), gin.Recovery())
 
@@ -115,18 +185,48 @@ func run() error {
 
 	v1.POST("/channels", channelHandler.Create)
 	v1.GET("/channels", channelHandler.List)
+	v1.GET("/channels/by-name/:name", channelHandler.GetByName)
 	v1.GET("/channels/:id", channelHandler.GetByID)
+	v1.PATCH("/channels/:id", channelHandler.Update)
+	v1.POST("/channels/:id/convert", channelHandler.Convert)
 
 	v1.POST("/channels/:id/messages", messageHandler.Create)
 	v1.GET("/channels/:id/messages", messageHandler.List)
+	v1.PATCH("/channels/:id/messages/:msgID", messageHandler.Edit)
+	v1.DELETE("/channels/:id/messages/:msgID", messageHandler.Delete)
+	v1.GET("/channels/:id/messages/:msgID/replies", messageHandler.ListReplies)
+	v1.POST("/channels/:id/messages/:msgID/reactions/:emoji", messageHandler.AddReaction)
+	v1.DELETE("/channels/:id/messages/:msgID/reactions/:emoji", messageHandler.RemoveReaction)
+
+	v1.POST("/channels/:id/read", messageHandler.MarkRead)
+
+	v1.GET("/channels/:id/pins", messageHandler.ListPins)
+	v1.POST("/channels/:id/pins/:msgID", messageHandler.AddPin)
+	v1.DELETE("/channels/:id/pins/:msgID", messageHandler.RemovePin)
+
+	v1.POST("/channels/:id/files", fileHandler.Upload)
+	v1.GET("/files/:id", fileHandler.Download)
+	v1.GET("/files/:id/thumbnails/:size", fileHandler.Thumbnail)
+
+	v1.GET("/search/messages", messageHandler.Search)
+
+	v1.GET("/scheduled-messages", messageHandler.ListScheduled)
+	v1.PATCH("/scheduled-messages/:id", messageHandler.UpdateScheduled)
+	v1.DELETE("/scheduled-messages/:id", messageHandler.CancelScheduled)
 
 	v1.POST("/channels/:id/join", membershipHandler.Join)
 	v1.POST("/channels/:id/leave", membershipHandler.Leave)
 	v1.POST("/channels/:id/invite", membershipHandler.Invite)
 	v1.GET("/channels/:id/members", membershipHandler.ListMembers)
+	v1.PATCH("/channels/:id/members/:userID", membershipHandler.UpdateMember)
+	v1.DELETE("/channels/:id/members/:userID", membershipHandler.RemoveMember)
 	v1.GET("/channels/:id/presence", presenceHandler.GetChannelPresence)
 
+	v1.POST("/dms", dmHandler.Open)
+
 	v1.GET("/users/me", userHandler.GetMe)
+	v1.GET("/users/me/unreads", messageHandler.Unreads)
+	v1.GET("/users/me/mentions", messageHandler.Mentions)
 
 	// --- Graceful shutdown ---
 	//
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+const (
+	// outboxInterval is how often each replica polls the outbox, which
+	// bounds how long a retried or orphaned event waits. New events wake
+	// the relay straight away.
+	outboxInterval = time.Second
+	// outboxPruneInterval is how often sent events are deleted.
+	outboxPruneInterval = time.Hour
+)
+
+// runOutboxRelay publishes outbox events until ctx is cancelled. Every
+// replica runs one; OutboxRelay.RelayPending makes that safe.
+func runOutboxRelay(ctx context.Context, relay *service.OutboxRelay, logger *zap.Logger) {
+	ticker := time.NewTicker(outboxInterval)
+	defer ticker.Stop()
+	prune := time.NewTicker(outboxPruneInterval)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-prune.C:
+			n, err := relay.Prune(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("outbox prune failed", zap.Error(err))
+			}
+			if n > 0 {
+				logger.Debug("pruned sent outbox events", zap.Int64("count", n))
+			}
+			continue
+		case <-ticker.C:
+		case <-relay.Woken():
+		}
+
+		n, err := relay.RelayPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("outbox relay failed", zap.Error(err))
+		}
+		if n > 0 {
+			logger.Debug("relayed outbox events", zap.Int("count", n))
+		}
+	}
+}
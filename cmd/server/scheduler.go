@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+// schedulerInterval is how often each replica looks for due scheduled
+// messages, i.e. the worst-case delivery delay.
+const schedulerInterval = 5 * time.Second
+
+// runScheduler delivers due scheduled messages until ctx is cancelled.
+// Every replica runs one; ScheduleService.DeliverDue makes that safe.
+func runScheduler(ctx context.Context, svc *service.ScheduleService, logger *zap.Logger) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := svc.DeliverDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("scheduled delivery failed", zap.Error(err))
+		}
+		if n > 0 {
+			logger.Debug("delivered scheduled messages", zap.Int("count", n))
+		}
+	}
+}
@@ -1,33 +1,35 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/lalith-99/echostream/internal/middleware"
 	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
 	"go.uber.org/zap"
 )
 
 type ChannelHandler struct {
 	repo       repository.ChannelRepository
 	membership repository.MembershipRepository
+	svc        *service.ChannelService
 	logger     *zap.Logger
 }
 
 // NewChannelHandler returns a ChannelHandler.
-func NewChannelHandler(repo repository.ChannelRepository, membership repository.MembershipRepository, logger *zap.Logger) *ChannelHandler {
-	return &ChannelHandler{repo: repo, membership: membership, logger: logger}
+func NewChannelHandler(repo repository.ChannelRepository, membership repository.MembershipRepository, svc *service.ChannelService, logger *zap.Logger) *ChannelHandler {
+	return &ChannelHandler{repo: repo, membership: membership, svc: svc, logger: logger}
 }
 
 type createChannelRequest struct {
-	Name      string `json:"name" binding:"required"`
-	IsPrivate bool   `json:"is_private"`
+	Name          string `json:"name" binding:"required"`
+	IsPrivate     bool   `json:"is_private"`
+	PinsAdminOnly bool   `json:"pins_admin_only"`
 }
 
-const maxChannelNameLen = 80
-
 // Create handles POST /v1/channels
 func (h *ChannelHandler) Create(c *gin.Context) {
 	var req createChannelRequest
@@ -36,27 +38,28 @@ func (h *ChannelHandler) Create(c *gin.Context) {
 		return
 	}
 
-	if len(req.Name) > maxChannelNameLen {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "channel name must be 80 characters or less"})
-		return
-	}
-
 	tenantID := middleware.GetTenantID(c)
 
-	ch, err := h.repo.Create(c.Request.Context(), tenantID, req.Name, req.IsPrivate)
+	// The name is normalized ("My Team" -> "my-team") and must be unique.
+	ch, err := h.svc.Create(c.Request.Context(), repository.CreateChannelParams{
+		TenantID:      tenantID,
+		Name:          req.Name,
+		IsPrivate:     req.IsPrivate,
+		PinsAdminOnly: req.PinsAdminOnly,
+		CreatedBy:     middleware.GetUserID(c),
+	})
 	if err != nil {
-		h.logger.Error("failed to create channel", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create channel"})
+		h.channelError(c, err, "failed to create channel")
 		return
 	}
 
 	c.JSON(http.StatusCreated, ch)
 
-	// Auto-add the creator as the first admin member.
+	// Auto-add the creator as the channel's owner.
 	// This runs after the response is sent (best-effort). If it fails, the
 	// creator can still join manually, but we log the error.
-	if err := h.membership.AddMember(c.Request.Context(), ch.ID, middleware.GetUserID(c), "admin"); err != nil {
-		h.logger.Error("failed to add creator as admin", zap.Error(err))
+	if _, err := h.membership.AddMember(c.Request.Context(), ch.ID, middleware.GetUserID(c), service.RoleOwner); err != nil {
+		h.logger.Error("failed to add creator as owner", zap.Error(err))
 	}
 }
 
@@ -66,7 +69,8 @@ func (h *ChannelHandler) List(c *gin.Context) {
 
 	limit, offset := parsePagination(c, 50, 100)
 
-	channels, err := h.repo.ListByTenant(c.Request.Context(), tenantID, limit, offset)
+	// Private channels are only listed for their members.
+	channels, err := h.repo.ListByTenant(c.Request.Context(), tenantID, middleware.GetUserID(c), limit, offset)
 	if err != nil {
 		h.logger.Error("failed to list channels", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list channels"})
@@ -100,3 +104,120 @@ func (h *ChannelHandler) GetByID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, ch)
 }
+
+// GetByName handles GET /v1/channels/by-name/:name
+//
+// The name is normalized the same way as on create, so "General" and
+// "#general" both find #general. Private channels are only found by their
+// members.
+func (h *ChannelHandler) GetByName(c *gin.Context) {
+	tenantID := middleware.GetTenantID(c)
+
+	ch, err := h.svc.GetByName(c.Request.Context(), tenantID, middleware.GetUserID(c), c.Param("name"))
+	if err != nil {
+		h.channelError(c, err, "failed to get channel")
+		return
+	}
+
+	c.JSON(http.StatusOK, ch)
+}
+
+type updateChannelRequest struct {
+	Name     *string `json:"name"`
+	Topic    *string `json:"topic"`
+	Purpose  *string `json:"purpose"`
+	Archived *bool   `json:"archived"`
+}
+
+// Update handles PATCH /v1/channels/:id
+//
+// Channel admins can rename the channel, set its topic and purpose, and
+// archive or unarchive it. Omitted fields are left unchanged.
+func (h *ChannelHandler) Update(c *gin.Context) {
+	var req updateChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	tenantID := middleware.GetTenantID(c)
+	userID := middleware.GetUserID(c)
+
+	ch, err := h.svc.Update(c.Request.Context(), tenantID, channelID, userID, repository.ChannelUpdate{
+		Name:     req.Name,
+		Topic:    req.Topic,
+		Purpose:  req.Purpose,
+		Archived: req.Archived,
+	})
+	if err != nil {
+		h.channelError(c, err, "failed to update channel")
+		return
+	}
+
+	c.JSON(http.StatusOK, ch)
+}
+
+// channelError maps ChannelService errors to HTTP responses. A name
+// conflict carries a "suggestion" the client can offer instead.
+func (h *ChannelHandler) channelError(c *gin.Context, err error, failMsg string) {
+	var taken *service.ChannelNameTakenError
+	switch {
+	case errors.As(err, &taken):
+		body := gin.H{"error": err.Error()}
+		if taken.Suggestion != "" {
+			body["suggestion"] = taken.Suggestion
+		}
+		c.JSON(http.StatusConflict, body)
+	case errors.Is(err, service.ErrInvalidChannelName), errors.Is(err, service.ErrTopicTooLong),
+		errors.Is(err, service.ErrPurposeTooLong), errors.Is(err, service.ErrConvertDM):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotMember), errors.Is(err, service.ErrNotChannelAdmin):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrChannelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrChannelArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(failMsg, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failMsg})
+	}
+}
+
+type convertChannelRequest struct {
+	IsPrivate *bool `json:"is_private" binding:"required"`
+}
+
+// Convert handles POST /v1/channels/:id/convert
+//
+// Channel admins can make a public channel private or the reverse.
+// Converting to an unchanged setting is a no-op that returns the channel.
+func (h *ChannelHandler) Convert(c *gin.Context) {
+	var req convertChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return
+	}
+
+	tenantID := middleware.GetTenantID(c)
+	userID := middleware.GetUserID(c)
+
+	ch, err := h.svc.Convert(c.Request.Context(), tenantID, channelID, userID, *req.IsPrivate)
+	if err != nil {
+		h.channelError(c, err, "failed to convert channel")
+		return
+	}
+
+	c.JSON(http.StatusOK, ch)
+}
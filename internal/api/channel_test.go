@@ -0,0 +1,552 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+func newTestChannelHandler(chRepo *mockChannelRepo, memRepo repository.MembershipRepository, pub *mockPublisher) *ChannelHandler {
+	var publisher service.EventPublisher
+	if pub != nil {
+		publisher = pub
+	}
+	svc := service.NewChannelService(chRepo, memRepo, publisher, nil, zap.NewNop())
+	return NewChannelHandler(chRepo, memRepo, svc, zap.NewNop())
+}
+
+func archivedChannels() *mockChannelRepo {
+	return &mockChannelRepo{
+		getByIDFn: func(_ context.Context, tenantID, channelID uuid.UUID) (*models.Channel, error) {
+			at := time.Now()
+			return &models.Channel{ID: channelID, TenantID: tenantID, Name: "old", ArchivedAt: &at}, nil
+		},
+	}
+}
+
+func patchChannel(t *testing.T, h *ChannelHandler, chID uuid.UUID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := channelRouter(h, uuid.New(), uuid.New())
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/v1/channels/"+chID.String(), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdateChannel_AdminRenamesAndPublishes(t *testing.T) {
+	var got repository.ChannelUpdate
+	chRepo := &mockChannelRepo{
+		updateFn: func(_ context.Context, tenantID, channelID uuid.UUID, u repository.ChannelUpdate) (*models.Channel, error) {
+			got = u
+			return &models.Channel{ID: channelID, TenantID: tenantID, Name: *u.Name, Topic: *u.Topic}, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepo{isMember: true, role: "admin"}, pub)
+
+	chID := uuid.New()
+	w := patchChannel(t, h, chID, `{"name":"  launch  ","topic":"ship it"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.Name == nil || *got.Name != "launch" {
+		t.Errorf("expected trimmed name, got %v", got.Name)
+	}
+	if got.Purpose != nil || got.Archived != nil {
+		t.Error("omitted fields should stay nil")
+	}
+
+	// One event for the channel's subscribers, one for the whole tenant.
+	if len(pub.published) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(pub.published))
+	}
+	if pub.published[0].channel != "ch:"+chID.String() {
+		t.Errorf("published to %q", pub.published[0].channel)
+	}
+	if !strings.HasPrefix(pub.published[1].channel, "tenant:") {
+		t.Errorf("expected a tenant event, published to %q", pub.published[1].channel)
+	}
+	for _, p := range pub.published {
+		var ev struct {
+			Type    string         `json:"type"`
+			Channel models.Channel `json:"channel"`
+		}
+		json.Unmarshal(p.payload, &ev)
+		if ev.Type != "channel_updated" || ev.Channel.Topic != "ship it" {
+			t.Errorf("unexpected event: %s", p.payload)
+		}
+	}
+}
+
+func TestUpdateChannel_ArchivePublishesChannelArchivedToTenant(t *testing.T) {
+	chRepo := &mockChannelRepo{
+		updateFn: func(_ context.Context, tenantID, channelID uuid.UUID, _ repository.ChannelUpdate) (*models.Channel, error) {
+			at := time.Now()
+			return &models.Channel{ID: channelID, TenantID: tenantID, Name: "test", ArchivedAt: &at}, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepo{isMember: true, role: "admin"}, pub)
+
+	w := patchChannel(t, h, uuid.New(), `{"archived":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 2 || !strings.Contains(string(pub.published[1].payload), `"channel_archived"`) {
+		t.Fatalf("expected channel_archived for the tenant, got %d events", len(pub.published))
+	}
+}
+
+func TestUpdateChannel_PrivateChannelStaysOffTenantTopic(t *testing.T) {
+	chRepo := &mockChannelRepo{
+		getByIDFn: func(_ context.Context, tenantID, channelID uuid.UUID) (*models.Channel, error) {
+			return &models.Channel{ID: channelID, TenantID: tenantID, Name: "secret", IsPrivate: true}, nil
+		},
+		updateFn: func(_ context.Context, tenantID, channelID uuid.UUID, u repository.ChannelUpdate) (*models.Channel, error) {
+			return &models.Channel{ID: channelID, TenantID: tenantID, Name: "secret", Topic: *u.Topic, IsPrivate: true}, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepo{isMember: true, role: "admin"}, pub)
+
+	w := patchChannel(t, h, uuid.New(), `{"topic":"shh"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 1 || !strings.HasPrefix(pub.published[0].channel, "ch:") {
+		t.Fatalf("expected only the channel event, got %+v", pub.published)
+	}
+}
+
+func TestUpdateChannel_NonAdmin(t *testing.T) {
+	h := newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepo{isMember: true}, nil)
+
+	w := patchChannel(t, h, uuid.New(), `{"topic":"hi"}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestUpdateChannel_Validation(t *testing.T) {
+	h := newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepo{isMember: true, role: "admin"}, nil)
+
+	for _, body := range []string{
+		`{"name":"   "}`,
+		`{"name":"` + strings.Repeat("a", 81) + `"}`,
+		`{"topic":"` + strings.Repeat("a", 251) + `"}`,
+		`{"purpose":"` + strings.Repeat("a", 251) + `"}`,
+	} {
+		w := patchChannel(t, h, uuid.New(), body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%.20s...: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestUpdateChannel_NoChangeSkipsWrite(t *testing.T) {
+	chRepo := &mockChannelRepo{
+		updateFn: func(context.Context, uuid.UUID, uuid.UUID, repository.ChannelUpdate) (*models.Channel, error) {
+			t.Error("Update should not be called")
+			return nil, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepo{isMember: true, role: "admin"}, pub)
+
+	w := patchChannel(t, h, uuid.New(), `{"name":"test","archived":false}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if len(pub.published) != 0 {
+		t.Errorf("expected no events, got %d", len(pub.published))
+	}
+}
+
+func TestUpdateChannel_ArchivedIsFrozen(t *testing.T) {
+	chRepo := archivedChannels()
+	h := newTestChannelHandler(chRepo, &mockMembershipRepo{isMember: true, role: "admin"}, nil)
+
+	w := patchChannel(t, h, uuid.New(), `{"topic":"still here?"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+
+	var got repository.ChannelUpdate
+	chRepo.updateFn = func(_ context.Context, tenantID, channelID uuid.UUID, u repository.ChannelUpdate) (*models.Channel, error) {
+		got = u
+		return &models.Channel{ID: channelID, TenantID: tenantID}, nil
+	}
+	w = patchChannel(t, h, uuid.New(), `{"archived":false}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unarchive: expected 200, got %d", w.Code)
+	}
+	if got.Archived == nil || *got.Archived {
+		t.Errorf("expected archived=false, got %v", got.Archived)
+	}
+}
+
+func TestSendMessage_ArchivedChannel(t *testing.T) {
+	h := newTestPinHandler(&mockMessageRepo{}, &mockMembershipRepo{isMember: true}, archivedChannels(), &mockPinRepo{}, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/messages",
+		strings.NewReader(`{"content":"hello?"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+func TestMessageWrites_ArchivedChannel(t *testing.T) {
+	uid := uuid.New()
+	msgs := &mockMessageRepo{
+		getFn: func(_ context.Context, _, channelID uuid.UUID, id int64) (*models.Message, error) {
+			return &models.Message{ID: id, ChannelID: channelID, SenderID: uid, Body: "hi"}, nil
+		},
+		editFn: func(_ context.Context, _, _ uuid.UUID, _ int64, _ uuid.UUID, _ string, _ []models.Mention) (*models.Message, []models.Mention, error) {
+			t.Fatal("edit reached the repository")
+			return nil, nil, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestPinHandler(msgs, &mockMembershipRepo{isMember: true}, archivedChannels(), &mockPinRepo{}, pub)
+	r := setupRouter(h, uid, uuid.New())
+	base := "/v1/channels/" + uuid.New().String()
+
+	for _, tc := range []struct{ method, path, body string }{
+		{"PATCH", base + "/messages/1", `{"content":"edited"}`},
+		{"DELETE", base + "/messages/1", ""},
+		{"POST", base + "/messages/1/reactions/thumbsup", ""},
+		{"DELETE", base + "/messages/1/reactions/thumbsup", ""},
+		{"POST", base + "/pins/1", ""},
+		{"DELETE", base + "/pins/1", ""},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s %s: expected 409, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+	if len(pub.published) != 0 {
+		t.Errorf("expected no events, got %d", len(pub.published))
+	}
+}
+
+func TestJoin_ArchivedChannel(t *testing.T) {
+	h := newTestMembershipHandler(&mockMembershipRepoFull{}, archivedChannels())
+	r := membershipRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/join", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+func postChannel(t *testing.T, h *ChannelHandler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := channelRouter(h, uuid.New(), uuid.New())
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestChannelCreate_NormalizesName(t *testing.T) {
+	var gotName string
+	chRepo := &mockChannelRepo{
+		createFn: func(_ context.Context, p repository.CreateChannelParams) (*models.Channel, error) {
+			gotName = p.Name
+			return &models.Channel{ID: uuid.New(), TenantID: p.TenantID, Name: p.Name}, nil
+		},
+	}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepoFull{}, nil)
+
+	w := postChannel(t, h, `{"name":"#Design Team"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotName != "design-team" {
+		t.Errorf("expected normalized name, got %q", gotName)
+	}
+
+	w = postChannel(t, h, `{"name":"what?"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for punctuation, got %d", w.Code)
+	}
+}
+
+func TestChannelCreate_PublishesToTenantUnlessPrivate(t *testing.T) {
+	chRepo := &mockChannelRepo{
+		createFn: func(_ context.Context, p repository.CreateChannelParams) (*models.Channel, error) {
+			return &models.Channel{ID: uuid.New(), TenantID: p.TenantID, Name: p.Name, IsPrivate: p.IsPrivate}, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepoFull{}, pub)
+
+	if w := postChannel(t, h, `{"name":"launch"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 1 || !strings.HasPrefix(pub.published[0].channel, "tenant:") ||
+		!strings.Contains(string(pub.published[0].payload), `"channel_created"`) {
+		t.Fatalf("expected channel_created on the tenant topic, got %+v", pub.published)
+	}
+
+	if w := postChannel(t, h, `{"name":"secret","is_private":true}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 1 {
+		t.Fatalf("private channels should not be announced, got %d events", len(pub.published))
+	}
+}
+
+func TestChannelCreate_NameTakenSuggestsAlternative(t *testing.T) {
+	taken := map[string]bool{"general": true, "general-2": true}
+	chRepo := &mockChannelRepo{
+		createFn: func(context.Context, repository.CreateChannelParams) (*models.Channel, error) {
+			return nil, repository.ErrChannelNameTaken
+		},
+		byNameFn: func(_ context.Context, tenantID uuid.UUID, name string) (*models.Channel, error) {
+			if taken[name] {
+				return &models.Channel{ID: uuid.New(), TenantID: tenantID, Name: name}, nil
+			}
+			return nil, nil
+		},
+	}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepoFull{}, nil)
+
+	w := postChannel(t, h, `{"name":"General"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Suggestion string `json:"suggestion"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Suggestion != "general-3" {
+		t.Errorf("expected suggestion general-3, got %q", resp.Suggestion)
+	}
+}
+
+func TestUpdateChannel_RenameConflict(t *testing.T) {
+	chRepo := &mockChannelRepo{
+		updateFn: func(context.Context, uuid.UUID, uuid.UUID, repository.ChannelUpdate) (*models.Channel, error) {
+			return nil, repository.ErrChannelNameTaken
+		},
+	}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepo{isMember: true, role: "admin"}, nil)
+
+	w := patchChannel(t, h, uuid.New(), `{"name":"random"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"suggestion":"random-2"`) {
+		t.Errorf("expected a suggestion, got %s", w.Body.String())
+	}
+}
+
+func TestChannelGetByName(t *testing.T) {
+	var gotName string
+	chRepo := &mockChannelRepo{
+		byNameFn: func(_ context.Context, tenantID uuid.UUID, name string) (*models.Channel, error) {
+			gotName = name
+			if name == "general" {
+				return &models.Channel{ID: uuid.New(), TenantID: tenantID, Name: name}, nil
+			}
+			return nil, nil
+		},
+	}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepoFull{}, nil)
+	r := channelRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/by-name/General", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if gotName != "general" {
+		t.Errorf("expected lookup by normalized name, got %q", gotName)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/channels/by-name/nope", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestChannelGetByName_PrivateHiddenFromNonMembers(t *testing.T) {
+	chRepo := &mockChannelRepo{
+		byNameFn: func(_ context.Context, tenantID uuid.UUID, name string) (*models.Channel, error) {
+			return &models.Channel{ID: uuid.New(), TenantID: tenantID, Name: name, IsPrivate: true, Topic: "secret plans"}, nil
+		},
+	}
+	for _, member := range []bool{false, true} {
+		h := newTestChannelHandler(chRepo, &mockMembershipRepoFull{isMember: member}, nil)
+		r := channelRouter(h, uuid.New(), uuid.New())
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/channels/by-name/board", nil)
+		r.ServeHTTP(w, req)
+
+		want := http.StatusNotFound
+		if member {
+			want = http.StatusOK
+		}
+		if w.Code != want {
+			t.Errorf("member=%v: expected %d, got %d: %s", member, want, w.Code, w.Body.String())
+		}
+	}
+}
+
+type fakeEvictor struct {
+	channelID uuid.UUID
+	retained  []uuid.UUID
+	evicted   []uuid.UUID
+	calls     int
+}
+
+func (f *fakeEvictor) EvictSubscribers(_ context.Context, channelID uuid.UUID, userIDs []uuid.UUID) error {
+	f.channelID, f.evicted = channelID, userIDs
+	f.calls++
+	return nil
+}
+
+func (f *fakeEvictor) RetainSubscribers(_ context.Context, channelID uuid.UUID, userIDs []uuid.UUID) error {
+	f.channelID, f.retained = channelID, userIDs
+	f.calls++
+	return nil
+}
+
+func convertChannel(t *testing.T, h *ChannelHandler, chID uuid.UUID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := channelRouter(h, uuid.New(), uuid.New())
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/convert", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestConvertChannel_ToPrivateEvictsNonMembers(t *testing.T) {
+	members := []uuid.UUID{uuid.New(), uuid.New()}
+	memRepo := &mockMembershipRepo{isMember: true, role: "admin", members: members}
+	ev := &fakeEvictor{}
+	pub := &mockPublisher{}
+	chRepo := &mockChannelRepo{}
+	svc := service.NewChannelService(chRepo, memRepo, pub, ev, zap.NewNop())
+	h := NewChannelHandler(chRepo, memRepo, svc, zap.NewNop())
+
+	chID := uuid.New()
+	w := convertChannel(t, h, chID, `{"is_private":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ev.calls != 1 || ev.channelID != chID || len(ev.retained) != 2 {
+		t.Errorf("expected eviction keeping 2 members of %s, got %+v", chID, ev)
+	}
+	// Members get the full channel; the tenant only learns it's gone.
+	if len(pub.published) != 2 || !strings.Contains(string(pub.published[0].payload), `"is_private":true`) {
+		t.Fatalf("expected channel_updated for the channel, got %d events", len(pub.published))
+	}
+	tenantEv := pub.published[1]
+	if !strings.HasPrefix(tenantEv.channel, "tenant:") ||
+		string(tenantEv.payload) != `{"type":"channel_hidden","channel_id":"`+chID.String()+`"}` {
+		t.Errorf("expected a bare channel_hidden on the tenant topic, got %s: %s", tenantEv.channel, tenantEv.payload)
+	}
+}
+
+func TestConvertChannel_ToPublicAndNoOpDontEvict(t *testing.T) {
+	memRepo := &mockMembershipRepo{isMember: true, role: "admin"}
+	ev := &fakeEvictor{}
+	pub := &mockPublisher{}
+	chRepo := &mockChannelRepo{}
+	svc := service.NewChannelService(chRepo, memRepo, pub, ev, zap.NewNop())
+	h := NewChannelHandler(chRepo, memRepo, svc, zap.NewNop())
+
+	if w := convertChannel(t, h, uuid.New(), `{"is_private":false}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	chRepo.privateFn = func(_ context.Context, tenantID, channelID, _ uuid.UUID, private bool) (*models.Channel, bool, error) {
+		return &models.Channel{ID: channelID, TenantID: tenantID, IsPrivate: private}, false, nil
+	}
+	if w := convertChannel(t, h, uuid.New(), `{"is_private":true}`); w.Code != http.StatusOK {
+		t.Fatalf("no-op: expected 200, got %d", w.Code)
+	}
+
+	if ev.calls != 0 {
+		t.Errorf("expected no evictions, got %d", ev.calls)
+	}
+	if len(pub.published) != 2 {
+		t.Errorf("expected only the real conversion to publish, got %d", len(pub.published))
+	}
+}
+
+func TestConvertChannel_Rejections(t *testing.T) {
+	dms := &mockChannelRepo{
+		getByIDFn: func(_ context.Context, tenantID, channelID uuid.UUID) (*models.Channel, error) {
+			return &models.Channel{ID: channelID, TenantID: tenantID, IsPrivate: true, Kind: models.ChannelKindDM}, nil
+		},
+	}
+	cases := []struct {
+		name string
+		h    *ChannelHandler
+		body string
+		want int
+	}{
+		{"missing field", newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepo{isMember: true, role: "admin"}, nil), `{}`, http.StatusBadRequest},
+		{"not admin", newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepo{isMember: true}, nil), `{"is_private":true}`, http.StatusForbidden},
+		{"dm", newTestChannelHandler(dms, &mockMembershipRepo{isMember: true, role: "admin"}, nil), `{"is_private":false}`, http.StatusBadRequest},
+		{"archived", newTestChannelHandler(archivedChannels(), &mockMembershipRepo{isMember: true, role: "admin"}, nil), `{"is_private":true}`, http.StatusConflict},
+	}
+	for _, tc := range cases {
+		if w := convertChannel(t, tc.h, uuid.New(), tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+}
+
+func TestChannelList_PassesViewer(t *testing.T) {
+	uid := uuid.New()
+	var gotViewer uuid.UUID
+	chRepo := &mockChannelRepo{
+		listFn: func(_ context.Context, _, viewerID uuid.UUID, _, _ int) ([]models.Channel, error) {
+			gotViewer = viewerID
+			return []models.Channel{}, nil
+		},
+	}
+	h := newTestChannelHandler(chRepo, &mockMembershipRepoFull{}, nil)
+	r := channelRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels", nil)
+	r.ServeHTTP(w, req)
+
+	if gotViewer != uid {
+		t.Errorf("expected viewer %s, got %s", uid, gotViewer)
+	}
+}
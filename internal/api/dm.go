@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+// maxDMParticipants caps group DMs, counting the caller.
+const maxDMParticipants = 8
+
+// DMHandler opens direct-message conversations.
+type DMHandler struct {
+	channels   repository.ChannelRepository
+	users      repository.UserRepository // needed to verify participants belong to caller's tenant
+	membership *service.MembershipService
+	logger     *zap.Logger
+}
+
+// NewDMHandler returns a DMHandler.
+func NewDMHandler(channels repository.ChannelRepository, users repository.UserRepository, membership *service.MembershipService, logger *zap.Logger) *DMHandler {
+	return &DMHandler{channels: channels, users: users, membership: membership, logger: logger}
+}
+
+type openDMRequest struct {
+	UserIDs []string `json:"user_ids" binding:"required"`
+}
+
+// Open handles POST /v1/dms
+//
+// Body: {"user_ids": ["<uuid>", ...]} — the other participants; the caller
+// is always included. One other user makes a "dm", more make a "group_dm".
+//
+// The same participant set always maps to the same channel, so this returns
+// 200 with the existing conversation, or 201 if a new one was created. The
+// other participants of a new one get an added_to_channel event.
+func (h *DMHandler) Open(c *gin.Context) {
+	var req openDMRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	memberIDs := []uuid.UUID{callerID}
+	for _, raw := range req.UserIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id: " + raw})
+			return
+		}
+		memberIDs = append(memberIDs, id)
+	}
+
+	// Sorted + de-duplicated so the participant set has one canonical form.
+	slices.SortFunc(memberIDs, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	memberIDs = slices.Compact(memberIDs)
+
+	if len(memberIDs) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a DM needs at least one other user"})
+		return
+	}
+	if len(memberIDs) > maxDMParticipants {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group DMs are limited to 8 participants"})
+		return
+	}
+
+	n, err := h.users.CountInTenant(c.Request.Context(), tenantID, memberIDs)
+	if err != nil {
+		h.logger.Error("failed to verify dm participants", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open DM"})
+		return
+	}
+	if n != len(memberIDs) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "one or more users not found"})
+		return
+	}
+
+	ch, created, err := h.channels.FindOrCreateDM(c.Request.Context(), tenantID, memberIDs)
+	if err != nil {
+		h.logger.Error("failed to open dm", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open DM"})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		h.membership.NotifyAdded(c.Request.Context(), ch, callerID, memberIDs)
+	}
+	c.JSON(status, ch)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+func newTestDMHandler(chRepo repository.ChannelRepository, users repository.UserRepository, pub *mockPublisher) *DMHandler {
+	var publisher service.EventPublisher
+	if pub != nil {
+		publisher = pub
+	}
+	svc := service.NewMembershipService(&mockMembershipRepoFull{}, chRepo, publisher, nil, zap.NewNop())
+	return NewDMHandler(chRepo, users, svc, zap.NewNop())
+}
+
+func dmRouter(h *DMHandler, uid, tid uuid.UUID) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, uid)
+		c.Set(middleware.ContextKeyTenantID, tid)
+		c.Next()
+	})
+	r.POST("/v1/dms", h.Open)
+	return r
+}
+
+func postDM(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/dms", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestOpenDM_CanonicalParticipantSet(t *testing.T) {
+	caller, other := uuid.New(), uuid.New()
+	var got []uuid.UUID
+	chRepo := &mockChannelRepo{
+		dmFn: func(_ context.Context, tid uuid.UUID, memberIDs []uuid.UUID) (*models.Channel, bool, error) {
+			got = memberIDs
+			return &models.Channel{ID: uuid.New(), TenantID: tid, Kind: models.ChannelKindDM}, true, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestDMHandler(chRepo, &mockUserRepo{}, pub)
+	r := dmRouter(h, caller, uuid.New())
+
+	// Duplicates and the caller's own ID collapse into one set of two.
+	w := postDM(r, `{"user_ids":["`+other.String()+`","`+other.String()+`","`+caller.String()+`"]}`)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 participants, got %v", got)
+	}
+	if strings.Compare(got[0].String(), got[1].String()) > 0 {
+		t.Fatalf("expected participants sorted, got %v", got)
+	}
+	// Only the other participant is told about the new DM.
+	if len(pub.published) != 1 || pub.published[0].channel != "user:"+other.String() ||
+		!strings.Contains(string(pub.published[0].payload), `"added_to_channel"`) {
+		t.Fatalf("expected added_to_channel for the other participant, got %+v", pub.published)
+	}
+}
+
+func TestOpenDM_ExistingReturns200(t *testing.T) {
+	chRepo := &mockChannelRepo{
+		dmFn: func(_ context.Context, tid uuid.UUID, _ []uuid.UUID) (*models.Channel, bool, error) {
+			return &models.Channel{ID: uuid.New(), TenantID: tid, Kind: models.ChannelKindDM}, false, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestDMHandler(chRepo, &mockUserRepo{}, pub)
+	r := dmRouter(h, uuid.New(), uuid.New())
+
+	w := postDM(r, `{"user_ids":["`+uuid.New().String()+`"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 0 {
+		t.Fatalf("reopening a DM should not notify anyone, got %+v", pub.published)
+	}
+}
+
+func TestOpenDM_OnlySelf(t *testing.T) {
+	caller := uuid.New()
+	h := newTestDMHandler(&mockChannelRepo{}, &mockUserRepo{}, nil)
+	r := dmRouter(h, caller, uuid.New())
+
+	w := postDM(r, `{"user_ids":["`+caller.String()+`"]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestOpenDM_TooManyParticipants(t *testing.T) {
+	ids := make([]string, maxDMParticipants) // + caller = 9
+	for i := range ids {
+		ids[i] = `"` + uuid.New().String() + `"`
+	}
+	h := newTestDMHandler(&mockChannelRepo{}, &mockUserRepo{}, nil)
+	r := dmRouter(h, uuid.New(), uuid.New())
+
+	w := postDM(r, `{"user_ids":[`+strings.Join(ids, ",")+`]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestOpenDM_UserInOtherTenant(t *testing.T) {
+	h := newTestDMHandler(&mockChannelRepo{}, &mockUserRepo{missing: 1}, nil)
+	r := dmRouter(h, uuid.New(), uuid.New())
+
+	w := postDM(r, `{"user_ids":["`+uuid.New().String()+`"]}`)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestInvite_RejectedForDM(t *testing.T) {
+	chRepo := &mockChannelRepo{
+		getByIDFn: func(_ context.Context, tid, chID uuid.UUID) (*models.Channel, error) {
+			return &models.Channel{ID: chID, TenantID: tid, IsPrivate: true, Kind: models.ChannelKindDM}, nil
+		},
+	}
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo)
+	r := membershipRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/invite",
+		strings.NewReader(`{"user_id":"`+uuid.New().String()+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
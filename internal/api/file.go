@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/media"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+const (
+	uploadFormField = "file"
+	// multipartOverhead allows for boundaries and part headers on top of
+	// the file itself when capping the request body.
+	multipartOverhead = 64 << 10
+)
+
+// inlineContentTypes are safe for a browser to render directly. Everything
+// else is served as a download.
+var inlineContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// FileHandler is a thin HTTP adapter over service.FileService.
+type FileHandler struct {
+	svc    *service.FileService
+	logger *zap.Logger
+}
+
+// NewFileHandler returns a FileHandler wired to a FileService.
+func NewFileHandler(svc *service.FileService, logger *zap.Logger) *FileHandler {
+	return &FileHandler{svc: svc, logger: logger}
+}
+
+// Upload handles POST /v1/channels/:id/files (multipart/form-data, field "file").
+//
+// The part is streamed straight into the blob store; nothing is buffered
+// in memory or spooled to a temp file by the multipart parser.
+func (h *FileHandler) Upload(c *gin.Context) {
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	limit, err := h.svc.UploadLimit(c.Request.Context(), tenantID)
+	if err != nil {
+		h.logger.Error("failed to load upload limit", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload file"})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected multipart/form-data"})
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "missing 'file' field"})
+			return
+		}
+		if err != nil {
+			h.uploadError(c, err)
+			return
+		}
+		if part.FormName() != uploadFormField {
+			part.Close()
+			continue
+		}
+
+		f, err := h.svc.Upload(c.Request.Context(), tenantID, channelID, userID, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			h.uploadError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, f)
+		return
+	}
+}
+
+func (h *FileHandler) uploadError(c *gin.Context, err error) {
+	var tooBig *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooBig), errors.Is(err, service.ErrFileTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrFileTooLarge.Error()})
+	case errors.Is(err, service.ErrEmptyFile), errors.Is(err, service.ErrInvalidFileName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("failed to upload file", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload file"})
+	}
+}
+
+// Download handles GET /v1/files/:id
+func (h *FileHandler) Download(c *gin.Context) {
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file ID"})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	f, rc, err := h.svc.Open(c.Request.Context(), tenantID, userID, fileID)
+	if err != nil {
+		h.openError(c, err)
+		return
+	}
+	defer rc.Close()
+
+	disposition := "attachment"
+	if inlineContentTypes[f.ContentType] {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, f.SizeBytes, f.ContentType, rc, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": f.Name}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=" + strconv.Itoa(24*60*60),
+	})
+}
+
+// Thumbnail handles GET /v1/files/:id/thumbnails/:size, where size is
+// one of the values in the file's "thumbnails" list.
+func (h *FileHandler) Thumbnail(c *gin.Context) {
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file ID"})
+		return
+	}
+	size, err := strconv.Atoi(c.Param("size"))
+	if err != nil || size <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid thumbnail size"})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	f, rc, err := h.svc.OpenThumbnail(c.Request.Context(), tenantID, userID, fileID, size)
+	if err != nil {
+		h.openError(c, err)
+		return
+	}
+	defer rc.Close()
+
+	// Thumbnails are always PNG or JPEG we encoded ourselves, so inline is safe.
+	c.DataFromReader(http.StatusOK, -1, media.ThumbnailContentType(f.ContentType), rc, map[string]string{
+		"Content-Disposition":    "inline",
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=" + strconv.Itoa(24*60*60),
+	})
+}
+
+func (h *FileHandler) openError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("failed to open file", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to download file"})
+	}
+}
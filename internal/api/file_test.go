@@ -0,0 +1,359 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
+	"github.com/lalith-99/echostream/internal/storage"
+	"go.uber.org/zap"
+)
+
+// mockFileRepo is an in-memory repository.FileRepository. The mutex
+// covers SetImageMetadata, which runs on a background goroutine.
+type mockFileRepo struct {
+	mu          sync.Mutex
+	files       map[uuid.UUID]models.File
+	attachments map[int64][]models.File // returned by ForMessages
+}
+
+func (m *mockFileRepo) Create(_ context.Context, f *models.File) (*models.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[uuid.UUID]models.File)
+	}
+	stored := *f
+	stored.CreatedAt = time.Now()
+	m.files[f.ID] = stored
+	return &stored, nil
+}
+
+func (m *mockFileRepo) GetByID(_ context.Context, tenantID, fileID uuid.UUID) (*models.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[fileID]
+	if !ok || f.TenantID != tenantID {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+func (m *mockFileRepo) GetByIDs(_ context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]models.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]models.File, 0)
+	for _, id := range ids {
+		if f, ok := m.files[id]; ok && f.TenantID == tenantID {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockFileRepo) ForMessages(_ context.Context, _ []int64) (map[int64][]models.File, error) {
+	return m.attachments, nil
+}
+
+func (m *mockFileRepo) SetImageMetadata(_ context.Context, fileID uuid.UUID, width, height int, blurhash string, thumbnails []int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f := m.files[fileID]
+	f.Width, f.Height, f.Blurhash, f.Thumbnails = &width, &height, &blurhash, thumbnails
+	m.files[fileID] = f
+	return nil
+}
+
+// mockTenantRepo implements repository.TenantRepository.
+type mockTenantRepo struct {
+	maxUpload int64
+}
+
+func (m *mockTenantRepo) Create(_ context.Context, name string) (*models.Tenant, error) {
+	return &models.Tenant{ID: uuid.New(), Name: name}, nil
+}
+func (m *mockTenantRepo) MaxUploadBytes(_ context.Context, _ uuid.UUID) (int64, error) {
+	return m.maxUpload, nil
+}
+
+func newTestFileHandler(t *testing.T, files *mockFileRepo, tenants *mockTenantRepo, memRepo *mockMembershipRepo) *FileHandler {
+	t.Helper()
+	blobs, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewFileService(files, tenants, memRepo, blobs, 1<<20, zap.NewNop())
+	t.Cleanup(svc.Wait) // background image jobs write into the temp dir
+	return NewFileHandler(svc, zap.NewNop())
+}
+
+func fileRouter(h *FileHandler, uid, tid uuid.UUID) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, uid)
+		c.Set(middleware.ContextKeyTenantID, tid)
+		c.Next()
+	})
+	r.POST("/v1/channels/:id/files", h.Upload)
+	r.GET("/v1/files/:id", h.Download)
+	r.GET("/v1/files/:id/thumbnails/:size", h.Thumbnail)
+	return r
+}
+
+func uploadRequest(t *testing.T, channelID uuid.UUID, name string, content []byte) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", "/v1/channels/"+channelID.String()+"/files", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestFileUploadAndDownload(t *testing.T) {
+	uid, tid, chID := uuid.New(), uuid.New(), uuid.New()
+	files := &mockFileRepo{}
+	h := newTestFileHandler(t, files, &mockTenantRepo{}, &mockMembershipRepo{isMember: true})
+	r := fileRouter(h, uid, tid)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, uploadRequest(t, chID, "../../shot.txt", pngHeader))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var f models.File
+	if err := json.Unmarshal(w.Body.Bytes(), &f); err != nil {
+		t.Fatal(err)
+	}
+	// The name is reduced to its base; the type comes from the bytes, not the extension.
+	if f.Name != "shot.txt" || f.ContentType != "image/png" || f.SizeBytes != int64(len(pngHeader)) {
+		t.Fatalf("unexpected file: %+v", f)
+	}
+	if f.ChannelID != chID || f.UploaderID != uid {
+		t.Fatalf("wrong owner: %+v", f)
+	}
+	if strings.Contains(w.Body.String(), "storage_key") {
+		t.Fatalf("storage key leaked: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/files/"+f.ID.String(), nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !bytes.Equal(w.Body.Bytes(), pngHeader) {
+		t.Fatalf("content mismatch: %q", w.Body.Bytes())
+	}
+	if got := w.Header().Get("Content-Type"); got != "image/png" {
+		t.Fatalf("content type = %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `inline; filename=shot.txt` {
+		t.Fatalf("content disposition = %q", got)
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Fatalf("missing nosniff, got %q", got)
+	}
+}
+
+func TestFileUpload_ImageMetadataAndThumbnails(t *testing.T) {
+	uid, tid, chID := uuid.New(), uuid.New(), uuid.New()
+	files := &mockFileRepo{}
+	h := newTestFileHandler(t, files, &mockTenantRepo{}, &mockMembershipRepo{isMember: true})
+	r := fileRouter(h, uid, tid)
+
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 800, 600)))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, uploadRequest(t, chID, "photo.png", img.Bytes()))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var f models.File
+	json.Unmarshal(w.Body.Bytes(), &f)
+
+	h.svc.Wait()
+
+	stored, _ := files.GetByID(context.Background(), tid, f.ID)
+	if stored.Width == nil || *stored.Width != 800 || *stored.Height != 600 {
+		t.Fatalf("dimensions not recorded: %+v", stored)
+	}
+	if stored.Blurhash == nil || len(*stored.Blurhash) != 28 {
+		t.Fatalf("blurhash not recorded: %+v", stored.Blurhash)
+	}
+	if !slices.Equal(stored.Thumbnails, []int{64, 360, 720}) {
+		t.Fatalf("thumbnails = %v", stored.Thumbnails)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/files/"+f.ID.String()+"/thumbnails/360", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	thumb, err := png.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 360 || b.Dy() != 270 {
+		t.Fatalf("thumbnail is %dx%d, want 360x270", b.Dx(), b.Dy())
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/files/"+f.ID.String()+"/thumbnails/100", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a size that wasn't generated, got %d", w.Code)
+	}
+}
+
+func TestFileUpload_TenantLimit(t *testing.T) {
+	h := newTestFileHandler(t, &mockFileRepo{}, &mockTenantRepo{maxUpload: 10}, &mockMembershipRepo{isMember: true})
+	r := fileRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, uploadRequest(t, uuid.New(), "big.bin", bytes.Repeat([]byte("x"), 11)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, uploadRequest(t, uuid.New(), "ok.bin", bytes.Repeat([]byte("x"), 10)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 at exactly the limit, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestFileUpload_Rejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		isMember bool
+		file     string
+		content  []byte
+		want     int
+	}{
+		{"not member", false, "a.txt", []byte("hi"), http.StatusForbidden},
+		{"empty file", true, "a.txt", nil, http.StatusBadRequest},
+		{"no file name", true, "", []byte("hi"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestFileHandler(t, &mockFileRepo{}, &mockTenantRepo{}, &mockMembershipRepo{isMember: tt.isMember})
+			r := fileRouter(h, uuid.New(), uuid.New())
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, uploadRequest(t, uuid.New(), tt.file, tt.content))
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFileDownload_Scoping(t *testing.T) {
+	tid, chID := uuid.New(), uuid.New()
+	fileID := uuid.New()
+	files := &mockFileRepo{files: map[uuid.UUID]models.File{
+		fileID: {ID: fileID, TenantID: tid, ChannelID: chID, Name: "a.txt", StorageKey: tid.String() + "/" + fileID.String()},
+	}}
+
+	tests := []struct {
+		name     string
+		tenantID uuid.UUID
+		isMember bool
+		want     int
+	}{
+		{"other tenant", uuid.New(), true, http.StatusNotFound},
+		{"not a channel member", tid, false, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestFileHandler(t, files, &mockTenantRepo{}, &mockMembershipRepo{isMember: tt.isMember})
+			r := fileRouter(h, uuid.New(), tt.tenantID)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/files/"+fileID.String(), nil)
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreate_WithAttachments(t *testing.T) {
+	uid, tid, chID := uuid.New(), uuid.New(), uuid.New()
+	own, foreign := uuid.New(), uuid.New()
+	files := &mockFileRepo{files: map[uuid.UUID]models.File{
+		own:     {ID: own, TenantID: tid, ChannelID: chID, UploaderID: uid, Name: "a.png"},
+		foreign: {ID: foreign, TenantID: tid, ChannelID: chID, UploaderID: uuid.New(), Name: "b.png"},
+	}}
+	var stored []uuid.UUID
+	msgRepo := &mockMessageRepo{
+		createFn: func(_ context.Context, p repository.CreateMessageParams) (*models.Message, bool, error) {
+			stored = p.AttachmentIDs
+			return &models.Message{ID: 1, ChannelID: p.ChannelID, SenderID: p.SenderID, Body: p.Body}, true, nil
+		},
+	}
+	svc := service.NewMessageService(msgRepo, &mockReactionRepo{}, &mockMembershipRepo{isMember: true}, &mockChannelRepo{}, &mockPinRepo{}, files, nil, nil, nil, zap.NewNop())
+	r := setupRouter(NewMessageHandler(svc, nil, zap.NewNop()), uid, tid)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/messages", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// An attachment-only message needs no text.
+	w := send(`{"attachment_ids":["` + own.String() + `"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var msg models.Message
+	if err := json.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0] != own {
+		t.Fatalf("attachment ids not passed to repo: %v", stored)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].ID != own {
+		t.Fatalf("attachments not returned: %+v", msg.Attachments)
+	}
+
+	for _, body := range []string{
+		`{"content":"hi","attachment_ids":["` + foreign.String() + `"]}`,    // someone else's upload
+		`{"content":"hi","attachment_ids":["` + uuid.New().String() + `"]}`, // unknown file
+		`{"content":"hi","attachment_ids":["` + own.String() + `","` + own.String() + `"]}`,
+	} {
+		if w := send(body); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
@@ -14,6 +14,9 @@ import (
 	"github.com/lalith-99/echostream/internal/middleware"
 	"github.com/lalith-99/echostream/internal/models"
 	"github.com/lalith-99/echostream/internal/presence"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
+	"github.com/lalith-99/echostream/internal/websocket"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -21,16 +24,20 @@ import (
 // --- channel mock ---
 
 type mockChannelRepo struct {
-	createFn  func(ctx context.Context, tenantID uuid.UUID, name string, isPrivate bool) (*models.Channel, error)
+	createFn  func(ctx context.Context, p repository.CreateChannelParams) (*models.Channel, error)
 	getByIDFn func(ctx context.Context, tenantID, channelID uuid.UUID) (*models.Channel, error)
-	listFn    func(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]models.Channel, error)
+	listFn    func(ctx context.Context, tenantID, viewerID uuid.UUID, limit, offset int) ([]models.Channel, error)
+	dmFn      func(ctx context.Context, tenantID uuid.UUID, memberIDs []uuid.UUID) (*models.Channel, bool, error)
+	byNameFn  func(ctx context.Context, tenantID uuid.UUID, name string) (*models.Channel, error)
+	updateFn  func(ctx context.Context, tenantID, channelID uuid.UUID, u repository.ChannelUpdate) (*models.Channel, error)
+	privateFn func(ctx context.Context, tenantID, channelID, actorID uuid.UUID, private bool) (*models.Channel, bool, error)
 }
 
-func (m *mockChannelRepo) Create(ctx context.Context, tenantID uuid.UUID, name string, isPrivate bool) (*models.Channel, error) {
+func (m *mockChannelRepo) Create(ctx context.Context, p repository.CreateChannelParams) (*models.Channel, error) {
 	if m.createFn != nil {
-		return m.createFn(ctx, tenantID, name, isPrivate)
+		return m.createFn(ctx, p)
 	}
-	return &models.Channel{ID: uuid.New(), TenantID: tenantID, Name: name, IsPrivate: isPrivate}, nil
+	return &models.Channel{ID: uuid.New(), TenantID: p.TenantID, Name: p.Name, IsPrivate: p.IsPrivate, PinsAdminOnly: p.PinsAdminOnly}, nil
 }
 
 func (m *mockChannelRepo) GetByID(ctx context.Context, tenantID, channelID uuid.UUID) (*models.Channel, error) {
@@ -40,13 +47,41 @@ func (m *mockChannelRepo) GetByID(ctx context.Context, tenantID, channelID uuid.
 	return &models.Channel{ID: channelID, TenantID: tenantID, Name: "test"}, nil
 }
 
-func (m *mockChannelRepo) ListByTenant(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]models.Channel, error) {
+func (m *mockChannelRepo) ListByTenant(ctx context.Context, tenantID, viewerID uuid.UUID, limit, offset int) ([]models.Channel, error) {
 	if m.listFn != nil {
-		return m.listFn(ctx, tenantID, limit, offset)
+		return m.listFn(ctx, tenantID, viewerID, limit, offset)
 	}
 	return []models.Channel{}, nil
 }
 
+func (m *mockChannelRepo) FindOrCreateDM(ctx context.Context, tenantID uuid.UUID, memberIDs []uuid.UUID) (*models.Channel, bool, error) {
+	if m.dmFn != nil {
+		return m.dmFn(ctx, tenantID, memberIDs)
+	}
+	return &models.Channel{ID: uuid.New(), TenantID: tenantID, IsPrivate: true, Kind: models.ChannelKindDM}, true, nil
+}
+
+func (m *mockChannelRepo) GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.Channel, error) {
+	if m.byNameFn != nil {
+		return m.byNameFn(ctx, tenantID, name)
+	}
+	return nil, nil
+}
+
+func (m *mockChannelRepo) Update(ctx context.Context, tenantID, channelID uuid.UUID, u repository.ChannelUpdate) (*models.Channel, error) {
+	if m.updateFn != nil {
+		return m.updateFn(ctx, tenantID, channelID, u)
+	}
+	return &models.Channel{ID: channelID, TenantID: tenantID, Name: "test"}, nil
+}
+
+func (m *mockChannelRepo) SetPrivate(ctx context.Context, tenantID, channelID, actorID uuid.UUID, private bool) (*models.Channel, bool, error) {
+	if m.privateFn != nil {
+		return m.privateFn(ctx, tenantID, channelID, actorID, private)
+	}
+	return &models.Channel{ID: channelID, TenantID: tenantID, Name: "test", IsPrivate: private}, true, nil
+}
+
 // --- helpers ---
 
 func channelRouter(h *ChannelHandler, uid, tid uuid.UUID) *gin.Engine {
@@ -58,14 +93,17 @@ func channelRouter(h *ChannelHandler, uid, tid uuid.UUID) *gin.Engine {
 	})
 	r.POST("/v1/channels", h.Create)
 	r.GET("/v1/channels", h.List)
+	r.GET("/v1/channels/by-name/:name", h.GetByName)
 	r.GET("/v1/channels/:id", h.GetByID)
+	r.PATCH("/v1/channels/:id", h.Update)
+	r.POST("/v1/channels/:id/convert", h.Convert)
 	return r
 }
 
 // --- tests ---
 
 func TestChannelCreate_Success(t *testing.T) {
-	h := NewChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, zap.NewNop())
+	h := newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, nil)
 	r := channelRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -80,7 +118,7 @@ func TestChannelCreate_Success(t *testing.T) {
 }
 
 func TestChannelCreate_MissingName(t *testing.T) {
-	h := NewChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, zap.NewNop())
+	h := newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, nil)
 	r := channelRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -96,7 +134,7 @@ func TestChannelCreate_MissingName(t *testing.T) {
 
 func TestChannelCreate_NameTooLong(t *testing.T) {
 	longName := strings.Repeat("a", 81)
-	h := NewChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, zap.NewNop())
+	h := newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, nil)
 	r := channelRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -112,7 +150,7 @@ func TestChannelCreate_NameTooLong(t *testing.T) {
 
 func TestChannelCreate_Name80Chars(t *testing.T) {
 	exactName := strings.Repeat("b", 80)
-	h := NewChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, zap.NewNop())
+	h := newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, nil)
 	r := channelRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -129,13 +167,13 @@ func TestChannelCreate_Name80Chars(t *testing.T) {
 func TestChannelList_PassesPagination(t *testing.T) {
 	var gotLimit, gotOffset int
 	repo := &mockChannelRepo{
-		listFn: func(_ context.Context, _ uuid.UUID, limit, offset int) ([]models.Channel, error) {
+		listFn: func(_ context.Context, _, _ uuid.UUID, limit, offset int) ([]models.Channel, error) {
 			gotLimit = limit
 			gotOffset = offset
 			return []models.Channel{}, nil
 		},
 	}
-	h := NewChannelHandler(repo, &mockMembershipRepoFull{}, zap.NewNop())
+	h := newTestChannelHandler(repo, &mockMembershipRepoFull{}, nil)
 	r := channelRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -156,13 +194,13 @@ func TestChannelList_PassesPagination(t *testing.T) {
 func TestChannelList_DefaultPagination(t *testing.T) {
 	var gotLimit, gotOffset int
 	repo := &mockChannelRepo{
-		listFn: func(_ context.Context, _ uuid.UUID, limit, offset int) ([]models.Channel, error) {
+		listFn: func(_ context.Context, _, _ uuid.UUID, limit, offset int) ([]models.Channel, error) {
 			gotLimit = limit
 			gotOffset = offset
 			return []models.Channel{}, nil
 		},
 	}
-	h := NewChannelHandler(repo, &mockMembershipRepoFull{}, zap.NewNop())
+	h := newTestChannelHandler(repo, &mockMembershipRepoFull{}, nil)
 	r := channelRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -183,7 +221,7 @@ func TestChannelGetByID_NotFound(t *testing.T) {
 			return nil, nil
 		},
 	}
-	h := NewChannelHandler(repo, &mockMembershipRepoFull{}, zap.NewNop())
+	h := newTestChannelHandler(repo, &mockMembershipRepoFull{}, nil)
 	r := channelRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -196,7 +234,7 @@ func TestChannelGetByID_NotFound(t *testing.T) {
 }
 
 func TestChannelGetByID_InvalidID(t *testing.T) {
-	h := NewChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, zap.NewNop())
+	h := newTestChannelHandler(&mockChannelRepo{}, &mockMembershipRepoFull{}, nil)
 	r := channelRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -217,13 +255,20 @@ type mockMembershipRepoFull struct {
 	listErr   error
 	isMember  bool
 	memberErr error
+	role      string
+	roles     map[uuid.UUID]string // per-user GetRole; overrides role when set
+
+	raced        bool // AddMember and RemoveMember change nothing, as if a concurrent request won
+	updatedRoles map[uuid.UUID]string
+	kicked       []uuid.UUID
+	newOwner     uuid.UUID
 }
 
-func (m *mockMembershipRepoFull) AddMember(_ context.Context, _, _ uuid.UUID, _ string) error {
-	return m.addErr
+func (m *mockMembershipRepoFull) AddMember(_ context.Context, _, _ uuid.UUID, _ string) (bool, error) {
+	return m.addErr == nil && !m.raced, m.addErr
 }
-func (m *mockMembershipRepoFull) RemoveMember(_ context.Context, _, _ uuid.UUID) error {
-	return m.removeErr
+func (m *mockMembershipRepoFull) RemoveMember(_ context.Context, _, _ uuid.UUID) (bool, error) {
+	return m.removeErr == nil && !m.raced, m.removeErr
 }
 func (m *mockMembershipRepoFull) ListMembers(_ context.Context, _ uuid.UUID, _, _ int) ([]models.ChannelMember, error) {
 	return m.members, m.listErr
@@ -231,6 +276,44 @@ func (m *mockMembershipRepoFull) ListMembers(_ context.Context, _ uuid.UUID, _,
 func (m *mockMembershipRepoFull) IsMember(_ context.Context, _, _ uuid.UUID) (bool, error) {
 	return m.isMember, m.memberErr
 }
+func (m *mockMembershipRepoFull) GetRole(_ context.Context, _, userID uuid.UUID) (string, error) {
+	if m.roles != nil {
+		return m.roles[userID], m.memberErr
+	}
+	return m.role, m.memberErr
+}
+func (m *mockMembershipRepoFull) UpdateRole(_ context.Context, _, _, _, userID uuid.UUID, role string) (bool, error) {
+	if m.updatedRoles == nil {
+		m.updatedRoles = make(map[uuid.UUID]string)
+	}
+	m.updatedRoles[userID] = role
+	return true, nil
+}
+func (m *mockMembershipRepoFull) TransferOwnership(_ context.Context, _, _, _, toID uuid.UUID) (bool, error) {
+	m.newOwner = toID
+	return true, nil
+}
+func (m *mockMembershipRepoFull) Kick(_ context.Context, _, _, _, userID uuid.UUID) (bool, error) {
+	m.kicked = append(m.kicked, userID)
+	return true, nil
+}
+func (m *mockMembershipRepoFull) ListMemberIDs(_ context.Context, _ uuid.UUID) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(m.members))
+	for i, mem := range m.members {
+		ids[i] = mem.UserID
+	}
+	return ids, m.listErr
+}
+func (m *mockMembershipRepoFull) MarkRead(_ context.Context, _, _ uuid.UUID, _ int64) (bool, error) {
+	return false, nil
+}
+func (m *mockMembershipRepoFull) Unreads(_ context.Context, _, _ uuid.UUID) ([]models.ChannelUnread, error) {
+	return nil, nil
+}
+
+func newTestMembershipHandler(repo repository.MembershipRepository, chRepo repository.ChannelRepository) *MembershipHandler {
+	return NewMembershipHandler(repo, chRepo, service.NewMembershipService(repo, chRepo, nil, nil, zap.NewNop()), zap.NewNop())
+}
 
 func membershipRouter(h *MembershipHandler, uid, tid uuid.UUID) *gin.Engine {
 	r := gin.New()
@@ -243,6 +326,8 @@ func membershipRouter(h *MembershipHandler, uid, tid uuid.UUID) *gin.Engine {
 	r.POST("/v1/channels/:id/leave", h.Leave)
 	r.POST("/v1/channels/:id/invite", h.Invite)
 	r.GET("/v1/channels/:id/members", h.ListMembers)
+	r.PATCH("/v1/channels/:id/members/:userID", h.UpdateMember)
+	r.DELETE("/v1/channels/:id/members/:userID", h.RemoveMember)
 	return r
 }
 
@@ -255,7 +340,7 @@ func TestJoin_InvalidRole(t *testing.T) {
 			return &models.Channel{ID: chID, TenantID: tid}, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -277,7 +362,7 @@ func TestJoin_ValidRoleMember(t *testing.T) {
 			return &models.Channel{ID: chID, TenantID: tid}, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -291,7 +376,8 @@ func TestJoin_ValidRoleMember(t *testing.T) {
 	}
 }
 
-func TestJoin_ValidRoleAdmin(t *testing.T) {
+// Self-joining can't grant a role; admins are promoted by another admin.
+func TestJoin_AdminRoleRejected(t *testing.T) {
 	tid := uuid.New()
 	chID := uuid.New()
 	chRepo := &mockChannelRepo{
@@ -299,7 +385,7 @@ func TestJoin_ValidRoleAdmin(t *testing.T) {
 			return &models.Channel{ID: chID, TenantID: tid}, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -308,8 +394,8 @@ func TestJoin_ValidRoleAdmin(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	r.ServeHTTP(w, req)
 
-	if w.Code != http.StatusNoContent {
-		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
@@ -321,7 +407,7 @@ func TestJoin_DefaultRole(t *testing.T) {
 			return &models.Channel{ID: chID, TenantID: tid}, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	// No body → defaults to "member"
@@ -341,7 +427,7 @@ func TestJoin_ChannelNotInTenant(t *testing.T) {
 			return nil, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{}, chRepo)
 	r := membershipRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -361,7 +447,7 @@ func TestLeave_ChannelNotInTenant(t *testing.T) {
 			return nil, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{}, chRepo)
 	r := membershipRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -389,7 +475,7 @@ func TestListMembers_Pagination(t *testing.T) {
 			{ChannelID: chID, UserID: uid2, Role: "member"},
 		},
 	}
-	h := NewMembershipHandler(memRepo, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(memRepo, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -411,7 +497,7 @@ func TestListMembers_Pagination(t *testing.T) {
 
 func TestListMembers_InvalidChannelID(t *testing.T) {
 	chRepo := &mockChannelRepo{}
-	h := NewMembershipHandler(&mockMembershipRepoFull{}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{}, chRepo)
 	r := membershipRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -433,6 +519,7 @@ type mockUserRepo struct {
 	getByEmailFn func(ctx context.Context, email string) (*models.User, error)
 	createFn     func(ctx context.Context, tenantID uuid.UUID, email, displayName, passwordHash string) (*models.User, error)
 	getByIDFn    func(ctx context.Context, tenantID, userID uuid.UUID) (*models.User, error)
+	missing      int // CountInTenant reports this many users as not found
 }
 
 func (m *mockUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
@@ -456,6 +543,10 @@ func (m *mockUserRepo) GetByID(ctx context.Context, tenantID, userID uuid.UUID)
 	return nil, nil
 }
 
+func (m *mockUserRepo) CountInTenant(_ context.Context, _ uuid.UUID, userIDs []uuid.UUID) (int, error) {
+	return len(userIDs) - m.missing, nil
+}
+
 type mockSignupRepo struct {
 	tenant *models.Tenant
 	user   *models.User
@@ -704,7 +795,7 @@ func TestJoin_PrivateChannel_NotInvited(t *testing.T) {
 		},
 	}
 	// isMember defaults to false → user hasn't been invited
-	h := NewMembershipHandler(&mockMembershipRepoFull{isMember: false}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: false}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -727,7 +818,7 @@ func TestJoin_PrivateChannel_Invited(t *testing.T) {
 		},
 	}
 	// isMember=true → user was previously invited
-	h := NewMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -750,7 +841,7 @@ func TestJoin_PublicChannel_NoMembershipCheckNeeded(t *testing.T) {
 		},
 	}
 	// isMember=false, but public channels don't check membership
-	h := NewMembershipHandler(&mockMembershipRepoFull{isMember: false}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: false}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -779,8 +870,8 @@ func TestInvite_Success(t *testing.T) {
 			return &models.Channel{ID: chID, TenantID: tid, IsPrivate: true}, nil
 		},
 	}
-	// caller is a member
-	h := NewMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo, zap.NewNop())
+	// caller is an admin, as private channels require
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: true, role: "admin"}, chRepo)
 	r := membershipRouter(h, callerID, tid)
 
 	w := httptest.NewRecorder()
@@ -806,7 +897,7 @@ func TestInvite_CallerNotMember(t *testing.T) {
 		},
 	}
 	// caller is NOT a member
-	h := NewMembershipHandler(&mockMembershipRepoFull{isMember: false}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: false}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -830,7 +921,7 @@ func TestInvite_InvalidUserID(t *testing.T) {
 			return &models.Channel{ID: chID, TenantID: tid}, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -854,7 +945,7 @@ func TestInvite_MissingBody(t *testing.T) {
 			return &models.Channel{ID: chID, TenantID: tid}, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo)
 	r := membershipRouter(h, uuid.New(), tid)
 
 	w := httptest.NewRecorder()
@@ -873,7 +964,7 @@ func TestInvite_ChannelNotFound(t *testing.T) {
 			return nil, nil
 		},
 	}
-	h := NewMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo, zap.NewNop())
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: true}, chRepo)
 	r := membershipRouter(h, uuid.New(), uuid.New())
 
 	w := httptest.NewRecorder()
@@ -888,11 +979,372 @@ func TestInvite_ChannelNotFound(t *testing.T) {
 	}
 }
 
+func TestInvite_PrivateChannel_MemberCannotInvite(t *testing.T) {
+	tid := uuid.New()
+	chID := uuid.New()
+
+	chRepo := &mockChannelRepo{
+		getByIDFn: func(_ context.Context, _, _ uuid.UUID) (*models.Channel, error) {
+			return &models.Channel{ID: chID, TenantID: tid, IsPrivate: true}, nil
+		},
+	}
+	h := newTestMembershipHandler(&mockMembershipRepoFull{isMember: true, role: "member"}, chRepo)
+	r := membershipRouter(h, uuid.New(), tid)
+
+	w := httptest.NewRecorder()
+	body := `{"user_id":"` + uuid.New().String() + `"}`
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/invite",
+		strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for member inviting to private channel, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// ==========================================================================
+// Member role and removal tests
+// ==========================================================================
+
+// memberTest is a public channel in which the caller and target hold the
+// given roles ("" = not a member).
+type memberTest struct {
+	r        *gin.Engine
+	repo     *mockMembershipRepoFull
+	pub      *mockPublisher
+	ev       *fakeEvictor
+	chID     uuid.UUID
+	callerID uuid.UUID
+	targetID uuid.UUID
+}
+
+func newMemberTest(callerRole, targetRole string) *memberTest {
+	tid := uuid.New()
+	mt := &memberTest{pub: &mockPublisher{}, ev: &fakeEvictor{}, chID: uuid.New(), callerID: uuid.New(), targetID: uuid.New()}
+
+	chRepo := &mockChannelRepo{
+		getByIDFn: func(_ context.Context, _, _ uuid.UUID) (*models.Channel, error) {
+			return &models.Channel{ID: mt.chID, TenantID: tid, Kind: "channel"}, nil
+		},
+	}
+	mt.repo = &mockMembershipRepoFull{roles: map[uuid.UUID]string{
+		mt.callerID: callerRole,
+		mt.targetID: targetRole,
+	}}
+	svc := service.NewMembershipService(mt.repo, chRepo, mt.pub, mt.ev, zap.NewNop())
+	mt.r = membershipRouter(NewMembershipHandler(mt.repo, chRepo, svc, zap.NewNop()), mt.callerID, tid)
+	return mt
+}
+
+func patchMember(r *gin.Engine, chID, targetID uuid.UUID, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/v1/channels/"+chID.String()+"/members/"+targetID.String(),
+		strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func deleteMember(r *gin.Engine, chID, targetID uuid.UUID) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/channels/"+chID.String()+"/members/"+targetID.String(), nil)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdateMember_AdminPromotesMember(t *testing.T) {
+	mt := newMemberTest("admin", "member")
+
+	w := patchMember(mt.r, mt.chID, mt.targetID, `{"role":"admin"}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if mt.repo.updatedRoles[mt.targetID] != "admin" {
+		t.Fatalf("expected target promoted to admin, got %q", mt.repo.updatedRoles[mt.targetID])
+	}
+}
+
+func TestUpdateMember_AdminCannotDemoteAdmin(t *testing.T) {
+	mt := newMemberTest("admin", "admin")
+
+	w := patchMember(mt.r, mt.chID, mt.targetID, `{"role":"member"}`)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mt.repo.updatedRoles) != 0 {
+		t.Fatal("role should not have changed")
+	}
+}
+
+func TestUpdateMember_OwnerDemotesAdmin(t *testing.T) {
+	mt := newMemberTest("owner", "admin")
+
+	w := patchMember(mt.r, mt.chID, mt.targetID, `{"role":"member"}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if mt.repo.updatedRoles[mt.targetID] != "member" {
+		t.Fatalf("expected target demoted to member, got %q", mt.repo.updatedRoles[mt.targetID])
+	}
+}
+
+func TestUpdateMember_MemberCannotPromote(t *testing.T) {
+	mt := newMemberTest("member", "member")
+
+	w := patchMember(mt.r, mt.chID, mt.targetID, `{"role":"admin"}`)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUpdateMember_TransferOwnership(t *testing.T) {
+	mt := newMemberTest("owner", "admin")
+
+	w := patchMember(mt.r, mt.chID, mt.targetID, `{"role":"owner"}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if mt.repo.newOwner != mt.targetID {
+		t.Fatal("expected ownership transferred to target")
+	}
+}
+
+func TestUpdateMember_AdminCannotTransferOwnership(t *testing.T) {
+	mt := newMemberTest("admin", "member")
+
+	w := patchMember(mt.r, mt.chID, mt.targetID, `{"role":"owner"}`)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if mt.repo.newOwner != uuid.Nil {
+		t.Fatal("ownership should not have moved")
+	}
+}
+
+func TestUpdateMember_InvalidRole(t *testing.T) {
+	mt := newMemberTest("owner", "member")
+
+	w := patchMember(mt.r, mt.chID, mt.targetID, `{"role":"superuser"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUpdateMember_TargetNotMember(t *testing.T) {
+	mt := newMemberTest("admin", "")
+
+	w := patchMember(mt.r, mt.chID, mt.targetID, `{"role":"admin"}`)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRemoveMember_AdminKicksMember(t *testing.T) {
+	mt := newMemberTest("admin", "member")
+
+	w := deleteMember(mt.r, mt.chID, mt.targetID)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mt.repo.kicked) != 1 || mt.repo.kicked[0] != mt.targetID {
+		t.Fatalf("expected target kicked, got %v", mt.repo.kicked)
+	}
+	if mt.ev.calls != 1 || len(mt.ev.evicted) != 1 || mt.ev.evicted[0] != mt.targetID {
+		t.Fatalf("expected the target's subscriptions evicted, got %+v", mt.ev)
+	}
+	if len(mt.pub.published) != 2 || mt.pub.published[0].channel != "user:"+mt.targetID.String() ||
+		!strings.Contains(string(mt.pub.published[0].payload), `"removed_from_channel"`) {
+		t.Fatalf("expected removed_from_channel on the target's user topic, got %+v", mt.pub.published)
+	}
+	assertMemberEvent(t, mt, mt.pub.published[1].payload, "member_left", mt.callerID, mt.targetID)
+}
+
+func TestRemoveMember_CannotKickOwner(t *testing.T) {
+	mt := newMemberTest("admin", "owner")
+
+	w := deleteMember(mt.r, mt.chID, mt.targetID)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mt.repo.kicked) != 0 {
+		t.Fatal("owner should not have been kicked")
+	}
+	if mt.ev.calls != 0 || len(mt.pub.published) != 0 {
+		t.Fatal("a refused kick should not evict or notify")
+	}
+}
+
+func TestRemoveMember_CannotKickSelf(t *testing.T) {
+	tid := uuid.New()
+	chID := uuid.New()
+	callerID := uuid.New()
+
+	chRepo := &mockChannelRepo{
+		getByIDFn: func(_ context.Context, _, _ uuid.UUID) (*models.Channel, error) {
+			return &models.Channel{ID: chID, TenantID: tid, Kind: "channel"}, nil
+		},
+	}
+	h := newTestMembershipHandler(&mockMembershipRepoFull{role: "owner"}, chRepo)
+	r := membershipRouter(h, callerID, tid)
+
+	w := deleteMember(r, chID, callerID)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLeave_EvictsAndNotifiesLeaver(t *testing.T) {
+	mt := newMemberTest("member", "member")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+mt.chID.String()+"/leave", nil)
+	mt.r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if mt.ev.channelID != mt.chID || len(mt.ev.evicted) != 1 || mt.ev.evicted[0] != mt.callerID {
+		t.Fatalf("expected the leaver's subscriptions evicted, got %+v", mt.ev)
+	}
+	if len(mt.pub.published) != 2 || mt.pub.published[0].channel != "user:"+mt.callerID.String() {
+		t.Fatalf("expected removed_from_channel on the leaver's user topic, got %+v", mt.pub.published)
+	}
+	if mt.pub.published[1].channel != "ch:"+mt.chID.String() {
+		t.Fatalf("expected member_left on the channel topic, got %s", mt.pub.published[1].channel)
+	}
+	assertMemberEvent(t, mt, mt.pub.published[1].payload, "member_left", mt.callerID, mt.callerID)
+}
+
+// assertMemberEvent checks a member_* event's type, actor and target.
+func assertMemberEvent(t *testing.T, mt *memberTest, payload []byte, eventType string, actorID, targetID uuid.UUID) {
+	t.Helper()
+	var ev websocket.OutboundEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Type != eventType || ev.ChannelID != mt.chID.String() ||
+		ev.UserID != actorID.String() || ev.TargetUserID != targetID.String() {
+		t.Fatalf("expected %s by %s for %s, got %+v", eventType, actorID, targetID, ev)
+	}
+}
+
+func TestJoin_PublishesMemberJoined(t *testing.T) {
+	mt := newMemberTest("", "")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+mt.chID.String()+"/join", nil)
+	mt.r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mt.pub.published) != 1 || mt.pub.published[0].channel != "ch:"+mt.chID.String() {
+		t.Fatalf("expected one event on the channel topic, got %+v", mt.pub.published)
+	}
+	assertMemberEvent(t, mt, mt.pub.published[0].payload, "member_joined", mt.callerID, mt.callerID)
+}
+
+func TestJoin_AlreadyMemberPublishesNothing(t *testing.T) {
+	mt := newMemberTest("member", "")
+	mt.repo.isMember = true
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+mt.chID.String()+"/join", nil)
+	mt.r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mt.pub.published) != 0 {
+		t.Fatalf("re-joining should not publish, got %+v", mt.pub.published)
+	}
+}
+
+func TestJoin_LostRacePublishesNothing(t *testing.T) {
+	mt := newMemberTest("", "")
+	mt.repo.raced = true
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+mt.chID.String()+"/join", nil)
+	mt.r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mt.pub.published) != 0 {
+		t.Fatalf("a join that added no row should not publish, got %+v", mt.pub.published)
+	}
+}
+
+func TestLeave_NotMemberPublishesNothing(t *testing.T) {
+	mt := newMemberTest("", "")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+mt.chID.String()+"/leave", nil)
+	mt.r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mt.pub.published) != 0 || len(mt.ev.evicted) != 0 {
+		t.Fatalf("expected no events or evictions, got %+v %+v", mt.pub.published, mt.ev)
+	}
+}
+
+func TestInvite_PublishesMemberInvited(t *testing.T) {
+	mt := newMemberTest("member", "")
+
+	w := httptest.NewRecorder()
+	body := `{"user_id":"` + mt.targetID.String() + `"}`
+	req, _ := http.NewRequest("POST", "/v1/channels/"+mt.chID.String()+"/invite", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	mt.r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mt.pub.published) != 2 || mt.pub.published[0].channel != "ch:"+mt.chID.String() {
+		t.Fatalf("expected member_invited then added_to_channel, got %+v", mt.pub.published)
+	}
+	assertMemberEvent(t, mt, mt.pub.published[0].payload, "member_invited", mt.callerID, mt.targetID)
+
+	added := mt.pub.published[1]
+	if added.channel != "user:"+mt.targetID.String() || !strings.Contains(string(added.payload), `"added_to_channel"`) {
+		t.Fatalf("expected added_to_channel on the target's user topic, got %s %s", added.channel, added.payload)
+	}
+}
+
+func TestLeave_OwnerMustTransferFirst(t *testing.T) {
+	tid := uuid.New()
+	chID := uuid.New()
+
+	chRepo := &mockChannelRepo{
+		getByIDFn: func(_ context.Context, _, _ uuid.UUID) (*models.Channel, error) {
+			return &models.Channel{ID: chID, TenantID: tid, Kind: "channel"}, nil
+		},
+	}
+	memRepo := &mockMembershipRepoFull{
+		role:    "owner",
+		members: []models.ChannelMember{{UserID: uuid.New()}, {UserID: uuid.New()}},
+	}
+	h := newTestMembershipHandler(memRepo, chRepo)
+	r := membershipRouter(h, uuid.New(), tid)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/leave", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 // ==========================================================================
-// Channel create auto-adds creator as admin
+// Channel create auto-adds creator as owner
 // ==========================================================================
 
-func TestChannelCreate_AddsCreatorAsAdmin(t *testing.T) {
+func TestChannelCreate_AddsCreatorAsOwner(t *testing.T) {
 	uid := uuid.New()
 	tid := uuid.New()
 	createdCh := &models.Channel{ID: uuid.New(), TenantID: tid, Name: "secret"}
@@ -912,11 +1364,11 @@ func TestChannelCreate_AddsCreatorAsAdmin(t *testing.T) {
 	}
 
 	chRepo := &mockChannelRepo{
-		createFn: func(_ context.Context, _ uuid.UUID, _ string, _ bool) (*models.Channel, error) {
+		createFn: func(_ context.Context, _ repository.CreateChannelParams) (*models.Channel, error) {
 			return createdCh, nil
 		},
 	}
-	h := NewChannelHandler(chRepo, customMemRepo, zap.NewNop())
+	h := newTestChannelHandler(chRepo, customMemRepo, nil)
 	r := channelRouter(h, uid, tid)
 
 	w := httptest.NewRecorder()
@@ -934,8 +1386,8 @@ func TestChannelCreate_AddsCreatorAsAdmin(t *testing.T) {
 	if addedUserID != uid {
 		t.Fatalf("expected AddMember called with user %s, got %s", uid, addedUserID)
 	}
-	if addedRole != "admin" {
-		t.Fatalf("expected AddMember called with role 'admin', got '%s'", addedRole)
+	if addedRole != "owner" {
+		t.Fatalf("expected AddMember called with role 'owner', got '%s'", addedRole)
 	}
 }
 
@@ -946,14 +1398,14 @@ type capturingMembershipRepo struct {
 	onAdd              func(channelID, userID uuid.UUID, role string)
 }
 
-func (c *capturingMembershipRepo) AddMember(_ context.Context, channelID, userID uuid.UUID, role string) error {
+func (c *capturingMembershipRepo) AddMember(_ context.Context, channelID, userID uuid.UUID, role string) (bool, error) {
 	if c.onAdd != nil {
 		c.onAdd(channelID, userID, role)
 	}
-	return nil
+	return true, nil
 }
 
-func (c *capturingMembershipRepo) RemoveMember(ctx context.Context, chID, uID uuid.UUID) error {
+func (c *capturingMembershipRepo) RemoveMember(ctx context.Context, chID, uID uuid.UUID) (bool, error) {
 	return c.MembershipRepoFull.RemoveMember(ctx, chID, uID)
 }
 
@@ -965,6 +1417,10 @@ func (c *capturingMembershipRepo) IsMember(ctx context.Context, chID, uID uuid.U
 	return c.MembershipRepoFull.IsMember(ctx, chID, uID)
 }
 
+func (c *capturingMembershipRepo) GetRole(ctx context.Context, chID, uID uuid.UUID) (string, error) {
+	return c.MembershipRepoFull.GetRole(ctx, chID, uID)
+}
+
 // ==========================================================================
 // Presence handler tests
 // ==========================================================================
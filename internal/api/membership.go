@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,30 +9,28 @@ import (
 	"github.com/lalith-99/echostream/internal/middleware"
 	"github.com/lalith-99/echostream/internal/models"
 	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
 	"go.uber.org/zap"
 )
 
-// MembershipHandler handles channel membership operations.
+// MembershipHandler handles channel membership operations. Who may do
+// what is decided by service.MembershipService.
 type MembershipHandler struct {
 	repo     repository.MembershipRepository
 	channels repository.ChannelRepository // needed to verify channel belongs to caller's tenant
+	svc      *service.MembershipService
 	logger   *zap.Logger
 }
 
 // NewMembershipHandler returns a MembershipHandler.
-func NewMembershipHandler(repo repository.MembershipRepository, channels repository.ChannelRepository, logger *zap.Logger) *MembershipHandler {
-	return &MembershipHandler{repo: repo, channels: channels, logger: logger}
+func NewMembershipHandler(repo repository.MembershipRepository, channels repository.ChannelRepository, svc *service.MembershipService, logger *zap.Logger) *MembershipHandler {
+	return &MembershipHandler{repo: repo, channels: channels, svc: svc, logger: logger}
 }
 
 type joinChannelRequest struct {
 	Role string `json:"role"`
 }
 
-var validRoles = map[string]bool{
-	"member": true,
-	"admin":  true,
-}
-
 // verifyChannelTenant checks that the channel exists and belongs to the caller's tenant.
 // Returns the full Channel model so callers can inspect fields like IsPrivate.
 // On error it writes the HTTP response and returns nil.
@@ -56,51 +55,48 @@ func (h *MembershipHandler) verifyChannelTenant(c *gin.Context) *models.Channel
 	return ch
 }
 
+// parseChannelID reads the :id path param.
+// On error it writes the HTTP response and returns false.
+func parseChannelID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
 // Join handles POST /v1/channels/:id/join
 //
 // Public channels: anyone in the tenant can join.
 // Private channels: the caller must already be a member (i.e., they were invited).
 // This allows the invite flow to add the user first, then the user "accepts"
 // by calling join (or the invite auto-adds them and join is a no-op via ON CONFLICT).
+//
+// Everyone joins as a member; higher roles are granted through
+// PATCH /channels/:id/members/:userID.
 func (h *MembershipHandler) Join(c *gin.Context) {
-	ch := h.verifyChannelTenant(c)
-	if ch == nil {
-		return // error already written
-	}
-
-	userID := middleware.GetUserID(c)
-
-	// Private channels require an existing membership (set by Invite).
-	if ch.IsPrivate {
-		already, err := h.repo.IsMember(c.Request.Context(), ch.ID, userID)
-		if err != nil {
-			h.logger.Error("failed to check membership", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
-			return
-		}
-		if !already {
-			c.JSON(http.StatusForbidden, gin.H{"error": "private channel — invite required"})
-			return
-		}
+	channelID, ok := parseChannelID(c)
+	if !ok {
+		return
 	}
 
-	// Body is optional, default to "member" role.
+	// Body is optional. "role" is still accepted, but only as "member".
 	var req joinChannelRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		req.Role = "member"
-	}
-	if req.Role == "" {
-		req.Role = "member"
-	}
-	if !validRoles[req.Role] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be 'member' or 'admin'"})
+	_ = c.ShouldBindJSON(&req)
+	if req.Role != "" && req.Role != service.RoleMember {
+		if service.ValidRole(req.Role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you can only join as a member"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidRole.Error()})
+		}
 		return
 	}
 
-	err := h.repo.AddMember(c.Request.Context(), ch.ID, userID, req.Role)
-	if err != nil {
-		h.logger.Error("failed to join channel", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to join channel"})
+	tenantID := middleware.GetTenantID(c)
+	userID := middleware.GetUserID(c)
+	if err := h.svc.Join(c.Request.Context(), tenantID, channelID, userID); err != nil {
+		h.membershipError(c, err, "failed to join channel")
 		return
 	}
 
@@ -109,17 +105,19 @@ func (h *MembershipHandler) Join(c *gin.Context) {
 
 // Leave handles POST /v1/channels/:id/leave
 func (h *MembershipHandler) Leave(c *gin.Context) {
-	ch := h.verifyChannelTenant(c)
-	if ch == nil {
+	channelID, ok := parseChannelID(c)
+	if !ok {
 		return
 	}
 
+	tenantID := middleware.GetTenantID(c)
 	userID := middleware.GetUserID(c)
-
-	err := h.repo.RemoveMember(c.Request.Context(), ch.ID, userID)
-	if err != nil {
-		h.logger.Error("failed to leave channel", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to leave channel"})
+	if err := h.svc.Leave(c.Request.Context(), tenantID, channelID, userID); err != nil {
+		if errors.Is(err, service.ErrNotMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you are not a member of this channel"})
+			return
+		}
+		h.membershipError(c, err, "failed to leave channel")
 		return
 	}
 
@@ -128,55 +126,129 @@ func (h *MembershipHandler) Leave(c *gin.Context) {
 
 // Invite handles POST /v1/channels/:id/invite
 //
-// Adds another user to a channel. Only existing members can invite.
-// For private channels, this is the only way to gain access.
-// For public channels, it's a convenience (user could also self-join).
+// Adds another user to a channel. Only existing members can invite, and
+// only admins can invite to a private channel, where this is the only
+// way in. For public channels, it's a convenience (user could also self-join).
 type inviteRequest struct {
 	UserID string `json:"user_id" binding:"required"`
 }
 
 func (h *MembershipHandler) Invite(c *gin.Context) {
-	ch := h.verifyChannelTenant(c)
-	if ch == nil {
+	channelID, ok := parseChannelID(c)
+	if !ok {
 		return
 	}
 
-	callerID := middleware.GetUserID(c)
+	var req inviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Only existing members can invite others.
-	isMember, err := h.repo.IsMember(c.Request.Context(), ch.ID, callerID)
+	targetID, err := uuid.Parse(req.UserID)
 	if err != nil {
-		h.logger.Error("failed to check caller membership", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
 		return
 	}
-	if !isMember {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you must be a member to invite others"})
+
+	tenantID := middleware.GetTenantID(c)
+	callerID := middleware.GetUserID(c)
+	if err := h.svc.Invite(c.Request.Context(), tenantID, channelID, callerID, targetID); err != nil {
+		if errors.Is(err, service.ErrNotMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you must be a member to invite others"})
+			return
+		}
+		h.membershipError(c, err, "failed to invite user")
 		return
 	}
 
-	var req inviteRequest
+	c.Status(http.StatusNoContent)
+}
+
+type updateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UpdateMember handles PATCH /v1/channels/:id/members/:userID
+//
+// Changes a member's role. Setting "owner" transfers ownership from the
+// caller, who becomes an admin.
+func (h *MembershipHandler) UpdateMember(c *gin.Context) {
+	channelID, ok := parseChannelID(c)
+	if !ok {
+		return
+	}
+	targetID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req updateMemberRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	targetID, err := uuid.Parse(req.UserID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+	tenantID := middleware.GetTenantID(c)
+	callerID := middleware.GetUserID(c)
+	if err := h.svc.SetRole(c.Request.Context(), tenantID, channelID, callerID, targetID, req.Role); err != nil {
+		h.membershipError(c, err, "failed to update member")
 		return
 	}
 
-	// AddMember is idempotent (ON CONFLICT DO NOTHING), so re-inviting is harmless.
-	if err := h.repo.AddMember(c.Request.Context(), ch.ID, targetID, "member"); err != nil {
-		h.logger.Error("failed to invite user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to invite user"})
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember handles DELETE /v1/channels/:id/members/:userID
+func (h *MembershipHandler) RemoveMember(c *gin.Context) {
+	channelID, ok := parseChannelID(c)
+	if !ok {
+		return
+	}
+	targetID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	tenantID := middleware.GetTenantID(c)
+	callerID := middleware.GetUserID(c)
+	if err := h.svc.Remove(c.Request.Context(), tenantID, channelID, callerID, targetID); err != nil {
+		h.membershipError(c, err, "failed to remove member")
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// parseUserID reads the :userID path param.
+// On error it writes the HTTP response and returns false.
+func parseUserID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// membershipError maps MembershipService errors to HTTP responses.
+func (h *MembershipHandler) membershipError(c *gin.Context, err error, failMsg string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrDMMembership):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotMember), errors.Is(err, service.ErrInviteRequired),
+		errors.Is(err, service.ErrInviteNotAllowed), errors.Is(err, service.ErrCannotManageMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrChannelNotFound), errors.Is(err, service.ErrTargetNotMember):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrChannelArchived), errors.Is(err, service.ErrOwnerMustTransfer):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(failMsg, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failMsg})
+	}
+}
+
 // ListMembers handles GET /v1/channels/:id/members
 func (h *MembershipHandler) ListMembers(c *gin.Context) {
 	ch := h.verifyChannelTenant(c)
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"go.uber.org/zap"
+)
+
+// Mentions handles GET /v1/users/me/mentions?before=123&limit=50
+//
+// Paginate by passing the smallest returned id as ?before=.
+func (h *MessageHandler) Mentions(c *gin.Context) {
+	before, limit, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	mentions, err := h.svc.ListMentions(c.Request.Context(), tenantID, userID, before, limit)
+	if err != nil {
+		h.logger.Error("failed to list mentions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list mentions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mentions)
+}
@@ -4,26 +4,41 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/models"
 	"github.com/lalith-99/echostream/internal/service"
 	"go.uber.org/zap"
 )
 
-// MessageHandler is a thin HTTP adapter. All business logic lives in service.MessageService.
+// MessageHandler is a thin HTTP adapter. All business logic lives in
+// service.MessageService and service.ScheduleService.
 type MessageHandler struct {
-	svc    *service.MessageService
-	logger *zap.Logger
+	svc       *service.MessageService
+	scheduler *service.ScheduleService // nil = send_at and the scheduled-message routes are rejected
+	logger    *zap.Logger
 }
 
-// NewMessageHandler returns a MessageHandler wired to a MessageService.
-func NewMessageHandler(svc *service.MessageService, logger *zap.Logger) *MessageHandler {
-	return &MessageHandler{svc: svc, logger: logger}
+// NewMessageHandler returns a MessageHandler wired to its services.
+func NewMessageHandler(svc *service.MessageService, scheduler *service.ScheduleService, logger *zap.Logger) *MessageHandler {
+	return &MessageHandler{svc: svc, scheduler: scheduler, logger: logger}
 }
 
+// idempotencyKeyHeader is an alternative to client_msg_id in the body.
+const idempotencyKeyHeader = "Idempotency-Key"
+
 type createMessageRequest struct {
+	Content       string      `json:"content"`        // may be empty if attachment_ids is set
+	ParentID      int64       `json:"parent_id"`      // optional: reply in this message's thread
+	ClientMsgID   string      `json:"client_msg_id"`  // optional: retries with the same ID don't duplicate
+	AttachmentIDs []uuid.UUID `json:"attachment_ids"` // optional: files uploaded via POST /channels/:id/files
+	SendAt        *time.Time  `json:"send_at"`        // optional: schedule for later instead of sending now
+}
+
+type editMessageRequest struct {
 	Content string `json:"content" binding:"required"`
 }
 
@@ -44,13 +59,41 @@ func (h *MessageHandler) Create(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	tenantID := middleware.GetTenantID(c)
 
-	msg, err := h.svc.Send(c.Request.Context(), tenantID, channelID, userID, req.Content)
+	if req.ParentID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'parent_id'"})
+		return
+	}
+
+	clientMsgID := req.ClientMsgID
+	if key := c.GetHeader(idempotencyKeyHeader); key != "" {
+		if clientMsgID != "" && clientMsgID != key {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "client_msg_id and Idempotency-Key differ"})
+			return
+		}
+		clientMsgID = key
+	}
+
+	opts := service.SendOptions{
+		ParentID:      req.ParentID,
+		ClientMsgID:   clientMsgID,
+		AttachmentIDs: req.AttachmentIDs,
+	}
+	if req.SendAt != nil {
+		h.schedule(c, tenantID, channelID, userID, req.Content, *req.SendAt, opts)
+		return
+	}
+
+	msg, created, err := h.svc.Send(c.Request.Context(), tenantID, channelID, userID, req.Content, opts)
 	if err != nil {
 		switch {
-		case errors.Is(err, service.ErrEmptyBody), errors.Is(err, service.ErrBodyTooLong):
+		case errors.Is(err, service.ErrEmptyBody), errors.Is(err, service.ErrBodyTooLong),
+			errors.Is(err, service.ErrInvalidParent), errors.Is(err, service.ErrClientMsgIDTooLong),
+			errors.Is(err, service.ErrTooManyAttachments), errors.Is(err, service.ErrInvalidAttachment):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		case errors.Is(err, service.ErrNotMember):
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrChannelArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		default:
 			h.logger.Error("failed to send message", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send message"})
@@ -58,10 +101,18 @@ func (h *MessageHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if !created {
+		// Idempotent retry: nothing new was created.
+		c.JSON(http.StatusOK, msg)
+		return
+	}
 	c.JSON(http.StatusCreated, msg)
 }
 
 // List handles GET /v1/channels/:id/messages?before=123&limit=50
+//
+// With ?after=123 instead, it returns messages newer than 123 in
+// ascending order, for clients catching up after a reconnect.
 func (h *MessageHandler) List(c *gin.Context) {
 	channelID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -69,35 +120,192 @@ func (h *MessageHandler) List(c *gin.Context) {
 		return
 	}
 
-	var before int64
+	before, limit, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	tenantID := middleware.GetTenantID(c)
+	userID := middleware.GetUserID(c)
+
+	var messages []models.Message
+	if a := c.Query("after"); a != "" {
+		after, err := strconv.ParseInt(a, 10, 64)
+		if err != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'after' parameter"})
+			return
+		}
+		if before > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "use either 'before' or 'after', not both"})
+			return
+		}
+		messages, err = h.svc.ListAfter(c.Request.Context(), tenantID, channelID, userID, after, limit)
+		if errors.Is(err, service.ErrNotMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		messages, err = h.svc.List(c.Request.Context(), tenantID, channelID, userID, before, limit)
+	}
+	if err != nil {
+		h.logger.Error("failed to list messages", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// ListReplies handles GET /v1/channels/:id/messages/:msgID/replies?before=123&limit=50
+func (h *MessageHandler) ListReplies(c *gin.Context) {
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return
+	}
+
+	parentID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+
+	before, limit, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	tenantID := middleware.GetTenantID(c)
+	userID := middleware.GetUserID(c)
+	replies, err := h.svc.ListReplies(c.Request.Context(), tenantID, channelID, userID, parentID, before, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMessageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotMember):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to list replies", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list replies"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, replies)
+}
+
+// parseCursor reads the ?before= and ?limit= query params used by
+// message history endpoints. On error it writes the HTTP response and
+// returns false.
+func parseCursor(c *gin.Context) (before int64, limit int, ok bool) {
+	var err error
 	if b := c.Query("before"); b != "" {
 		before, err = strconv.ParseInt(b, 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'before' parameter"})
-			return
+			return 0, 0, false
 		}
 	}
 	if before < 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'before' parameter"})
-		return
+		return 0, 0, false
 	}
 
-	limit := 50
+	limit = 50
 	if l := c.Query("limit"); l != "" {
 		limit, err = strconv.Atoi(l)
 		if err != nil || limit < 1 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'limit' parameter"})
-			return
+			return 0, 0, false
 		}
 	}
+	return before, limit, true
+}
+
+// Edit handles PATCH /v1/channels/:id/messages/:msgID
+func (h *MessageHandler) Edit(c *gin.Context) {
+	var req editMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return
+	}
+
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
 
+	userID := middleware.GetUserID(c)
 	tenantID := middleware.GetTenantID(c)
-	messages, err := h.svc.List(c.Request.Context(), tenantID, channelID, before, limit)
+
+	msg, err := h.svc.Edit(c.Request.Context(), tenantID, channelID, messageID, userID, req.Content)
 	if err != nil {
-		h.logger.Error("failed to list messages", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list messages"})
+		switch {
+		case errors.Is(err, service.ErrEmptyBody), errors.Is(err, service.ErrBodyTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrMessageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotSender), errors.Is(err, service.ErrNotMember):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrChannelArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to edit message", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to edit message"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, messages)
+	c.JSON(http.StatusOK, msg)
+}
+
+// Delete handles DELETE /v1/channels/:id/messages/:msgID
+func (h *MessageHandler) Delete(c *gin.Context) {
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return
+	}
+
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	err = h.svc.Delete(c.Request.Context(), tenantID, channelID, messageID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMessageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotAllowed), errors.Is(err, service.ErrNotMember):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrChannelArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to delete message", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete message"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseMessageID reads the :msgID path param.
+// On error it writes the HTTP response and returns false.
+func parseMessageID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("msgID"), 10, 64)
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message ID"})
+		return 0, false
+	}
+	return id, true
 }
@@ -14,7 +14,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/lalith-99/echostream/internal/middleware"
 	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
 	"github.com/lalith-99/echostream/internal/service"
+	"github.com/lalith-99/echostream/internal/websocket"
 	"go.uber.org/zap"
 )
 
@@ -24,21 +26,35 @@ func init() { gin.SetMode(gin.TestMode) }
 
 // mockMessageRepo implements repository.MessageRepository.
 type mockMessageRepo struct {
-	createFn func(ctx context.Context, tenantID, channelID, senderID uuid.UUID, body string) (*models.Message, error)
-	listFn   func(ctx context.Context, tenantID, channelID uuid.UUID, before int64, limit int) ([]models.Message, error)
+	createFn  func(ctx context.Context, p repository.CreateMessageParams) (*models.Message, bool, error)
+	listFn    func(ctx context.Context, tenantID, channelID uuid.UUID, before int64, limit int) ([]models.Message, error)
+	afterFn   func(ctx context.Context, tenantID, channelID uuid.UUID, after int64, limit int) ([]models.Message, error)
+	repliesFn func(ctx context.Context, tenantID, channelID uuid.UUID, parentID, before int64, limit int) ([]models.Message, error)
+	getFn     func(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64) (*models.Message, error)
+	editFn    func(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, editorID uuid.UUID, body string, mentions []models.Mention) (*models.Message, []models.Mention, error)
+	deleteFn  func(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, deletedBy uuid.UUID) (*models.Message, bool, error)
+	searchFn  func(ctx context.Context, tenantID, viewerID uuid.UUID, q repository.MessageSearch, before int64, limit int) ([]models.SearchResult, error)
+	mentionFn func(ctx context.Context, tenantID, userID uuid.UUID, before int64, limit int) ([]models.MentionResult, error)
 }
 
-func (m *mockMessageRepo) Create(ctx context.Context, tenantID, channelID, senderID uuid.UUID, body string) (*models.Message, error) {
+func (m *mockMessageRepo) Create(ctx context.Context, p repository.CreateMessageParams) (*models.Message, bool, error) {
 	if m.createFn != nil {
-		return m.createFn(ctx, tenantID, channelID, senderID, body)
+		return m.createFn(ctx, p)
 	}
-	return &models.Message{
+	msg := &models.Message{
 		ID:        1,
-		ChannelID: channelID,
-		SenderID:  senderID,
-		Body:      body,
+		ChannelID: p.ChannelID,
+		SenderID:  p.SenderID,
+		Body:      p.Body,
 		CreatedAt: time.Now(),
-	}, nil
+	}
+	if p.ParentID > 0 {
+		msg.ParentID = &p.ParentID
+	}
+	if p.ClientMsgID != "" {
+		msg.ClientMsgID = &p.ClientMsgID
+	}
+	return msg, true, nil
 }
 
 func (m *mockMessageRepo) ListByChannel(ctx context.Context, tenantID, channelID uuid.UUID, before int64, limit int) ([]models.Message, error) {
@@ -48,20 +64,130 @@ func (m *mockMessageRepo) ListByChannel(ctx context.Context, tenantID, channelID
 	return []models.Message{}, nil
 }
 
+func (m *mockMessageRepo) ListAfter(ctx context.Context, tenantID, channelID uuid.UUID, after int64, limit int) ([]models.Message, error) {
+	if m.afterFn != nil {
+		return m.afterFn(ctx, tenantID, channelID, after, limit)
+	}
+	return []models.Message{}, nil
+}
+
+func (m *mockMessageRepo) ListReplies(ctx context.Context, tenantID, channelID uuid.UUID, parentID, before int64, limit int) ([]models.Message, error) {
+	if m.repliesFn != nil {
+		return m.repliesFn(ctx, tenantID, channelID, parentID, before, limit)
+	}
+	return []models.Message{}, nil
+}
+
+func (m *mockMessageRepo) Search(ctx context.Context, tenantID, viewerID uuid.UUID, q repository.MessageSearch, before int64, limit int) ([]models.SearchResult, error) {
+	if m.searchFn != nil {
+		return m.searchFn(ctx, tenantID, viewerID, q, before, limit)
+	}
+	return []models.SearchResult{}, nil
+}
+
+func (m *mockMessageRepo) ListMentions(ctx context.Context, tenantID, userID uuid.UUID, before int64, limit int) ([]models.MentionResult, error) {
+	if m.mentionFn != nil {
+		return m.mentionFn(ctx, tenantID, userID, before, limit)
+	}
+	return []models.MentionResult{}, nil
+}
+
+func (m *mockMessageRepo) GetByID(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64) (*models.Message, error) {
+	if m.getFn != nil {
+		return m.getFn(ctx, tenantID, channelID, messageID)
+	}
+	return nil, nil
+}
+
+func (m *mockMessageRepo) Edit(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, editorID uuid.UUID, body string, mentions []models.Mention) (*models.Message, []models.Mention, error) {
+	if m.editFn != nil {
+		return m.editFn(ctx, tenantID, channelID, messageID, editorID, body, mentions)
+	}
+	now := time.Now()
+	return &models.Message{
+		ID:        messageID,
+		ChannelID: channelID,
+		SenderID:  editorID,
+		Body:      body,
+		EditedAt:  &now,
+	}, mentions, nil
+}
+
+func (m *mockMessageRepo) SoftDelete(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, deletedBy uuid.UUID) (*models.Message, bool, error) {
+	if m.deleteFn != nil {
+		return m.deleteFn(ctx, tenantID, channelID, messageID, deletedBy)
+	}
+	now := time.Now()
+	return &models.Message{
+		ID:        messageID,
+		ChannelID: channelID,
+		DeletedAt: &now,
+		DeletedBy: &deletedBy,
+	}, false, nil
+}
+
 // mockMembershipRepo implements repository.MembershipRepository.
 type mockMembershipRepo struct {
 	isMember bool
+	role     string // returned by GetRole; defaults to "member" when isMember is set
 	err      error
+	moved    bool // returned by MarkRead
+	unreads  []models.ChannelUnread
+	members  []uuid.UUID // returned by ListMemberIDs
 }
 
 func (m *mockMembershipRepo) IsMember(_ context.Context, _, _ uuid.UUID) (bool, error) {
 	return m.isMember, m.err
 }
-func (m *mockMembershipRepo) AddMember(_ context.Context, _, _ uuid.UUID, _ string) error { return nil }
-func (m *mockMembershipRepo) RemoveMember(_ context.Context, _, _ uuid.UUID) error        { return nil }
+func (m *mockMembershipRepo) GetRole(_ context.Context, _, _ uuid.UUID) (string, error) {
+	if m.role == "" && m.isMember {
+		return "member", m.err
+	}
+	return m.role, m.err
+}
+func (m *mockMembershipRepo) AddMember(_ context.Context, _, _ uuid.UUID, _ string) (bool, error) {
+	return true, nil
+}
+func (m *mockMembershipRepo) RemoveMember(_ context.Context, _, _ uuid.UUID) (bool, error) {
+	return true, nil
+}
 func (m *mockMembershipRepo) ListMembers(_ context.Context, _ uuid.UUID, _, _ int) ([]models.ChannelMember, error) {
 	return nil, nil
 }
+func (m *mockMembershipRepo) UpdateRole(_ context.Context, _, _, _, _ uuid.UUID, _ string) (bool, error) {
+	return m.isMember, m.err
+}
+func (m *mockMembershipRepo) TransferOwnership(_ context.Context, _, _, _, _ uuid.UUID) (bool, error) {
+	return m.isMember, m.err
+}
+func (m *mockMembershipRepo) Kick(_ context.Context, _, _, _, _ uuid.UUID) (bool, error) {
+	return m.isMember, m.err
+}
+func (m *mockMembershipRepo) ListMemberIDs(_ context.Context, _ uuid.UUID) ([]uuid.UUID, error) {
+	return m.members, m.err
+}
+func (m *mockMembershipRepo) MarkRead(_ context.Context, _, _ uuid.UUID, _ int64) (bool, error) {
+	return m.moved, m.err
+}
+func (m *mockMembershipRepo) Unreads(_ context.Context, _, _ uuid.UUID) ([]models.ChannelUnread, error) {
+	return m.unreads, m.err
+}
+
+// mockReactionRepo implements repository.ReactionRepository.
+type mockReactionRepo struct {
+	added     bool // returned by Add and Remove
+	summaries map[int64][]models.ReactionSummary
+}
+
+func (m *mockReactionRepo) Add(_ context.Context, _ int64, _ uuid.UUID, _ string) (bool, error) {
+	return m.added, nil
+}
+func (m *mockReactionRepo) Remove(_ context.Context, _ int64, _ uuid.UUID, _ string) (bool, error) {
+	return m.added, nil
+}
+func (m *mockReactionRepo) Summaries(_ context.Context, _ []int64, _ uuid.UUID) (map[int64][]models.ReactionSummary, error) {
+	return m.summaries, nil
+}
 
 // mockPublisher implements service.EventPublisher.
 type mockPublisher struct {
@@ -85,6 +211,10 @@ func (m *mockPublisher) Publish(_ context.Context, channel string, payload []byt
 // newTestHandler builds a MessageHandler with configurable mocks.
 // isMember=true means the sender passes the membership check.
 func newTestHandler(msgRepo *mockMessageRepo, memRepo *mockMembershipRepo, pub *mockPublisher) *MessageHandler {
+	return newTestHandlerWithReactions(msgRepo, &mockReactionRepo{added: true}, memRepo, pub)
+}
+
+func newTestHandlerWithReactions(msgRepo *mockMessageRepo, reactRepo *mockReactionRepo, memRepo *mockMembershipRepo, pub *mockPublisher) *MessageHandler {
 	if msgRepo == nil {
 		msgRepo = &mockMessageRepo{}
 	}
@@ -95,8 +225,8 @@ func newTestHandler(msgRepo *mockMessageRepo, memRepo *mockMembershipRepo, pub *
 	if pub != nil {
 		publisher = pub
 	}
-	svc := service.NewMessageService(msgRepo, memRepo, publisher, zap.NewNop())
-	return NewMessageHandler(svc, zap.NewNop())
+	svc := service.NewMessageService(msgRepo, reactRepo, memRepo, &mockChannelRepo{}, &mockPinRepo{}, nil, nil, publisher, nil, zap.NewNop())
+	return NewMessageHandler(svc, nil, zap.NewNop())
 }
 
 func setupRouter(h *MessageHandler, uid, tid uuid.UUID) *gin.Engine {
@@ -109,6 +239,18 @@ func setupRouter(h *MessageHandler, uid, tid uuid.UUID) *gin.Engine {
 	})
 	r.POST("/v1/channels/:id/messages", h.Create)
 	r.GET("/v1/channels/:id/messages", h.List)
+	r.PATCH("/v1/channels/:id/messages/:msgID", h.Edit)
+	r.DELETE("/v1/channels/:id/messages/:msgID", h.Delete)
+	r.GET("/v1/channels/:id/messages/:msgID/replies", h.ListReplies)
+	r.GET("/v1/search/messages", h.Search)
+	r.POST("/v1/channels/:id/messages/:msgID/reactions/:emoji", h.AddReaction)
+	r.DELETE("/v1/channels/:id/messages/:msgID/reactions/:emoji", h.RemoveReaction)
+	r.POST("/v1/channels/:id/read", h.MarkRead)
+	r.GET("/v1/channels/:id/pins", h.ListPins)
+	r.POST("/v1/channels/:id/pins/:msgID", h.AddPin)
+	r.DELETE("/v1/channels/:id/pins/:msgID", h.RemovePin)
+	r.GET("/v1/users/me/unreads", h.Unreads)
+	r.GET("/v1/users/me/mentions", h.Mentions)
 	return r
 }
 
@@ -186,8 +328,8 @@ func TestCreate_NotMember(t *testing.T) {
 
 func TestCreate_RepoError(t *testing.T) {
 	repo := &mockMessageRepo{
-		createFn: func(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, string) (*models.Message, error) {
-			return nil, errors.New("db down")
+		createFn: func(context.Context, repository.CreateMessageParams) (*models.Message, bool, error) {
+			return nil, false, errors.New("db down")
 		},
 	}
 	h := newTestHandler(repo, nil, nil)
@@ -204,6 +346,85 @@ func TestCreate_RepoError(t *testing.T) {
 	}
 }
 
+func TestCreate_ClientMsgIDEchoed(t *testing.T) {
+	pub := &mockPublisher{}
+	h := newTestHandler(nil, nil, pub)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/messages",
+		strings.NewReader(`{"content":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "tmp-1")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var ev struct {
+		Message struct {
+			ClientMsgID string `json:"client_msg_id"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(pub.published[0].payload, &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Message.ClientMsgID != "tmp-1" {
+		t.Fatalf("expected client_msg_id echoed in event, got %q", ev.Message.ClientMsgID)
+	}
+}
+
+func TestCreate_RetryReturnsOriginalWithoutPublishing(t *testing.T) {
+	original := &models.Message{ID: 77, Body: "first try"}
+	repo := &mockMessageRepo{
+		createFn: func(_ context.Context, p repository.CreateMessageParams) (*models.Message, bool, error) {
+			if p.ClientMsgID != "tmp-1" {
+				t.Fatalf("expected client_msg_id passed to repo, got %q", p.ClientMsgID)
+			}
+			return original, false, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestHandler(repo, nil, pub)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/messages",
+		strings.NewReader(`{"content":"second try","client_msg_id":"tmp-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a retry, got %d: %s", w.Code, w.Body.String())
+	}
+	var got models.Message
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.ID != 77 {
+		t.Fatalf("expected original message 77, got %d", got.ID)
+	}
+	if len(pub.published) != 0 {
+		t.Fatalf("expected no publish on retry, got %d", len(pub.published))
+	}
+}
+
+func TestCreate_ConflictingIdempotencyKeys(t *testing.T) {
+	h := newTestHandler(nil, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/messages",
+		strings.NewReader(`{"content":"hi","client_msg_id":"a"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "b")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
 func TestCreate_NilPublisher(t *testing.T) {
 	h := newTestHandler(nil, nil, nil)
 	r := setupRouter(h, uuid.New(), uuid.New())
@@ -276,3 +497,727 @@ func TestList_InvalidBefore(t *testing.T) {
 		t.Fatalf("expected 400, got %d", w.Code)
 	}
 }
+
+func TestList_After(t *testing.T) {
+	var gotAfter int64
+	repo := &mockMessageRepo{
+		afterFn: func(_ context.Context, _, _ uuid.UUID, after int64, _ int) ([]models.Message, error) {
+			gotAfter = after
+			return []models.Message{{ID: 11, Body: "a"}, {ID: 12, Body: "b"}}, nil
+		},
+	}
+	h := newTestHandler(repo, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/"+uuid.New().String()+"/messages?after=10", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotAfter != 10 {
+		t.Fatalf("expected after=10 passed to repo, got %d", gotAfter)
+	}
+	var msgs []models.Message
+	if err := json.NewDecoder(w.Body).Decode(&msgs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].ID != 11 {
+		t.Fatalf("expected ascending messages, got %+v", msgs)
+	}
+}
+
+func TestList_AfterAndBefore(t *testing.T) {
+	h := newTestHandler(nil, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/"+uuid.New().String()+"/messages?after=1&before=5", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestList_AfterNotMember(t *testing.T) {
+	h := newTestHandler(nil, &mockMembershipRepo{isMember: false}, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/"+uuid.New().String()+"/messages?after=1", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+// ownMessageRepo returns a repo whose GetByID finds a message sent by senderID.
+func ownMessageRepo(senderID uuid.UUID) *mockMessageRepo {
+	return &mockMessageRepo{
+		getFn: func(_ context.Context, _, channelID uuid.UUID, messageID int64) (*models.Message, error) {
+			return &models.Message{ID: messageID, ChannelID: channelID, SenderID: senderID, Body: "old"}, nil
+		},
+	}
+}
+
+func TestEdit_Success(t *testing.T) {
+	uid, chID := uuid.New(), uuid.New()
+	pub := &mockPublisher{}
+	h := newTestHandler(ownMessageRepo(uid), nil, pub)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/v1/channels/"+chID.String()+"/messages/7",
+		strings.NewReader(`{"content":"fixed typo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var msg models.Message
+	if err := json.NewDecoder(w.Body).Decode(&msg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if msg.Body != "fixed typo" || msg.EditedAt == nil {
+		t.Fatalf("expected edited body with edited_at, got %+v", msg)
+	}
+
+	if len(pub.published) != 1 {
+		t.Fatalf("expected 1 publish, got %d", len(pub.published))
+	}
+	var ev struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(pub.published[0].payload, &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Type != "message_updated" {
+		t.Fatalf("expected message_updated event, got %s", ev.Type)
+	}
+}
+
+func TestEdit_NotSender(t *testing.T) {
+	h := newTestHandler(ownMessageRepo(uuid.New()), nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/v1/channels/"+uuid.New().String()+"/messages/7",
+		strings.NewReader(`{"content":"hijack"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestEdit_NotFound(t *testing.T) {
+	h := newTestHandler(nil, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/v1/channels/"+uuid.New().String()+"/messages/7",
+		strings.NewReader(`{"content":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestEdit_InvalidMessageID(t *testing.T) {
+	h := newTestHandler(nil, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/v1/channels/"+uuid.New().String()+"/messages/abc",
+		strings.NewReader(`{"content":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestDelete_OwnMessage(t *testing.T) {
+	uid, chID := uuid.New(), uuid.New()
+	pub := &mockPublisher{}
+	h := newTestHandler(ownMessageRepo(uid), nil, pub)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/channels/"+chID.String()+"/messages/7", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 1 {
+		t.Fatalf("expected 1 publish, got %d", len(pub.published))
+	}
+	var ev struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(pub.published[0].payload, &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Type != "message_deleted" {
+		t.Fatalf("expected message_deleted event, got %s", ev.Type)
+	}
+}
+
+func TestDelete_ReplyRepublishesParent(t *testing.T) {
+	uid := uuid.New()
+	parentID := int64(3)
+	repo := &mockMessageRepo{
+		getFn: func(_ context.Context, _, channelID uuid.UUID, messageID int64) (*models.Message, error) {
+			if messageID == parentID {
+				return &models.Message{ID: parentID, ChannelID: channelID}, nil
+			}
+			return &models.Message{ID: messageID, ChannelID: channelID, SenderID: uid, ParentID: &parentID}, nil
+		},
+		deleteFn: func(_ context.Context, _, channelID uuid.UUID, messageID int64, _ uuid.UUID) (*models.Message, bool, error) {
+			now := time.Now()
+			return &models.Message{ID: messageID, ChannelID: channelID, ParentID: &parentID, DeletedAt: &now}, false, nil
+		},
+	}
+	pub := &mockPublisher{}
+	h := newTestHandler(repo, nil, pub)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/channels/"+uuid.New().String()+"/messages/7", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 2 {
+		t.Fatalf("expected 2 publishes, got %d", len(pub.published))
+	}
+	var ev struct {
+		Type    string         `json:"type"`
+		Message models.Message `json:"message"`
+	}
+	if err := json.Unmarshal(pub.published[1].payload, &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Type != "message_updated" || ev.Message.ID != parentID {
+		t.Fatalf("expected message_updated for parent %d, got %s for %d", parentID, ev.Type, ev.Message.ID)
+	}
+}
+
+func TestDelete_OthersMessageAsMember(t *testing.T) {
+	h := newTestHandler(ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true, role: "member"}, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/channels/"+uuid.New().String()+"/messages/7", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDelete_OthersMessageAsAdmin(t *testing.T) {
+	h := newTestHandler(ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true, role: "admin"}, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/channels/"+uuid.New().String()+"/messages/7", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDelete_AlreadyDeleted(t *testing.T) {
+	uid := uuid.New()
+	repo := &mockMessageRepo{
+		getFn: func(_ context.Context, _, channelID uuid.UUID, messageID int64) (*models.Message, error) {
+			now := time.Now()
+			return &models.Message{ID: messageID, ChannelID: channelID, SenderID: uid, DeletedAt: &now}, nil
+		},
+	}
+	h := newTestHandler(repo, nil, nil)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/channels/"+uuid.New().String()+"/messages/7", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreate_ThreadReply(t *testing.T) {
+	chID := uuid.New()
+	pub := &mockPublisher{}
+	h := newTestHandler(ownMessageRepo(uuid.New()), nil, pub)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/messages",
+		strings.NewReader(`{"content":"agreed","parent_id":7}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// thread_reply for the reply, then message_updated for the parent's counters.
+	if len(pub.published) != 2 {
+		t.Fatalf("expected 2 publishes, got %d", len(pub.published))
+	}
+	for i, want := range []string{"thread_reply", "message_updated"} {
+		var ev struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(pub.published[i].payload, &ev); err != nil {
+			t.Fatalf("unmarshal event: %v", err)
+		}
+		if ev.Type != want {
+			t.Fatalf("event %d: expected %s, got %s", i, want, ev.Type)
+		}
+	}
+}
+
+func TestCreate_ReplyToReply(t *testing.T) {
+	repo := &mockMessageRepo{
+		getFn: func(_ context.Context, _, channelID uuid.UUID, messageID int64) (*models.Message, error) {
+			grandparent := int64(1)
+			return &models.Message{ID: messageID, ChannelID: channelID, ParentID: &grandparent}, nil
+		},
+	}
+	h := newTestHandler(repo, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/messages",
+		strings.NewReader(`{"content":"nested","parent_id":7}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestListReplies_Success(t *testing.T) {
+	var gotParent, gotBefore int64
+	repo := ownMessageRepo(uuid.New())
+	repo.repliesFn = func(_ context.Context, _, _ uuid.UUID, parentID, before int64, _ int) ([]models.Message, error) {
+		gotParent, gotBefore = parentID, before
+		return []models.Message{{ID: 9, ParentID: &parentID, Body: "reply"}}, nil
+	}
+	h := newTestHandler(repo, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/"+uuid.New().String()+"/messages/7/replies?before=20", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotParent != 7 || gotBefore != 20 {
+		t.Fatalf("expected parent=7 before=20, got parent=%d before=%d", gotParent, gotBefore)
+	}
+}
+
+func TestListReplies_ParentNotFound(t *testing.T) {
+	h := newTestHandler(nil, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/"+uuid.New().String()+"/messages/7/replies", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestListReplies_NonMemberForbidden(t *testing.T) {
+	repo := ownMessageRepo(uuid.New())
+	repo.repliesFn = func(_ context.Context, _, _ uuid.UUID, _, _ int64, _ int) ([]models.Message, error) {
+		t.Fatal("replies listed for a non-member")
+		return nil, nil
+	}
+	h := newTestHandler(repo, &mockMembershipRepo{isMember: false}, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/"+uuid.New().String()+"/messages/7/replies", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAddReaction_Success(t *testing.T) {
+	chID := uuid.New()
+	pub := &mockPublisher{}
+	h := newTestHandler(ownMessageRepo(uuid.New()), nil, pub)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/messages/7/reactions/thumbsup", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 1 {
+		t.Fatalf("expected 1 publish, got %d", len(pub.published))
+	}
+	var ev struct {
+		Type      string `json:"type"`
+		MessageID int64  `json:"message_id"`
+		Emoji     string `json:"emoji"`
+	}
+	if err := json.Unmarshal(pub.published[0].payload, &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Type != "reaction_added" || ev.MessageID != 7 || ev.Emoji != "thumbsup" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
+
+func TestAddReaction_DuplicateDoesNotPublish(t *testing.T) {
+	pub := &mockPublisher{}
+	h := newTestHandlerWithReactions(ownMessageRepo(uuid.New()), &mockReactionRepo{added: false}, nil, pub)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/messages/7/reactions/thumbsup", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 0 {
+		t.Fatalf("expected no publish for duplicate reaction, got %d", len(pub.published))
+	}
+}
+
+func TestRemoveReaction_NotMember(t *testing.T) {
+	h := newTestHandler(ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: false}, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/channels/"+uuid.New().String()+"/messages/7/reactions/thumbsup", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestList_IncludesReactions(t *testing.T) {
+	repo := &mockMessageRepo{
+		listFn: func(_ context.Context, _, _ uuid.UUID, _ int64, _ int) ([]models.Message, error) {
+			return []models.Message{{ID: 2, Body: "second"}, {ID: 1, Body: "first"}}, nil
+		},
+	}
+	reactions := &mockReactionRepo{summaries: map[int64][]models.ReactionSummary{
+		1: {{Emoji: "tada", Count: 3, Reacted: true}},
+	}}
+	h := newTestHandlerWithReactions(repo, reactions, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/"+uuid.New().String()+"/messages", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var msgs []models.Message
+	if err := json.NewDecoder(w.Body).Decode(&msgs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(msgs[0].Reactions) != 0 {
+		t.Fatalf("expected no reactions on message 2, got %+v", msgs[0].Reactions)
+	}
+	if len(msgs[1].Reactions) != 1 || msgs[1].Reactions[0].Count != 3 || !msgs[1].Reactions[0].Reacted {
+		t.Fatalf("unexpected reactions on message 1: %+v", msgs[1].Reactions)
+	}
+}
+
+func TestSearch_PassesParsedQuery(t *testing.T) {
+	uid := uuid.New()
+	var got repository.MessageSearch
+	var gotBefore int64
+	repo := &mockMessageRepo{
+		searchFn: func(_ context.Context, _, _ uuid.UUID, q repository.MessageSearch, before int64, _ int) ([]models.SearchResult, error) {
+			got, gotBefore = q, before
+			return []models.SearchResult{{Message: models.Message{ID: 3}, ChannelName: "general", Snippet: "<mark>deploy</mark>"}}, nil
+		},
+	}
+	h := newTestHandler(repo, nil, nil)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/search/messages?q=deploy+from:me&before=40", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.Text != "deploy" || got.SenderID != uid || gotBefore != 40 {
+		t.Fatalf("unexpected search params: %+v before=%d", got, gotBefore)
+	}
+}
+
+func TestSearch_EmptyQuery(t *testing.T) {
+	h := newTestHandler(nil, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/search/messages?q=", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestMarkRead_PublishesToUser(t *testing.T) {
+	uid, chID := uuid.New(), uuid.New()
+	pub := &mockPublisher{}
+	h := newTestHandler(ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true, moved: true}, pub)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/read",
+		strings.NewReader(`{"message_id":42}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 1 {
+		t.Fatalf("expected 1 publish, got %d", len(pub.published))
+	}
+	if pub.published[0].channel != "user:"+uid.String() {
+		t.Fatalf("expected read_marker on the user topic, got %s", pub.published[0].channel)
+	}
+	var ev struct {
+		Type      string `json:"type"`
+		ChannelID string `json:"channel_id"`
+		MessageID int64  `json:"message_id"`
+	}
+	if err := json.Unmarshal(pub.published[0].payload, &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Type != "read_marker" || ev.ChannelID != chID.String() || ev.MessageID != 42 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
+
+func TestMarkRead_StaleCursorDoesNotPublish(t *testing.T) {
+	pub := &mockPublisher{}
+	h := newTestHandler(ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true, moved: false}, pub)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/read",
+		strings.NewReader(`{"message_id":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 0 {
+		t.Fatalf("expected no publish for stale cursor, got %d", len(pub.published))
+	}
+}
+
+func TestMarkRead_MessageNotFound(t *testing.T) {
+	h := newTestHandler(nil, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/read",
+		strings.NewReader(`{"message_id":99}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMarkRead_MissingMessageID(t *testing.T) {
+	h := newTestHandler(nil, nil, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/read",
+		strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUnreads_Success(t *testing.T) {
+	chID := uuid.New()
+	mem := &mockMembershipRepo{unreads: []models.ChannelUnread{
+		{ChannelID: chID, LastReadID: 10, UnreadCount: 4, MentionCount: 1},
+	}}
+	h := newTestHandler(nil, mem, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/users/me/unreads", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got []models.ChannelUnread
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(got) != 1 || got[0].UnreadCount != 4 || got[0].MentionCount != 1 {
+		t.Fatalf("unexpected unreads: %+v", got)
+	}
+}
+
+func TestWSSendMessage_MapsServiceErrors(t *testing.T) {
+	svc := service.NewMessageService(&mockMessageRepo{}, &mockReactionRepo{}, &mockMembershipRepo{isMember: false}, &mockChannelRepo{}, &mockPinRepo{}, nil, nil, nil, nil, zap.NewNop())
+	h := NewWSHandler(nil, nil, svc, "secret", zap.NewNop())
+
+	_, err := h.sendMessage(context.Background(), uuid.New(), uuid.New(), uuid.New(), "hi", 0, "")
+
+	var sendErr *websocket.SendError
+	if !errors.As(err, &sendErr) || sendErr.Code != "not_member" {
+		t.Fatalf("expected not_member SendError, got %v", err)
+	}
+}
+
+func TestCreate_MentionsStoredAndNotified(t *testing.T) {
+	sender, alice, outsider := uuid.New(), uuid.New(), uuid.New()
+	var stored []models.Mention
+	repo := &mockMessageRepo{
+		createFn: func(_ context.Context, p repository.CreateMessageParams) (*models.Message, bool, error) {
+			stored = p.Mentions
+			return &models.Message{ID: 5, ChannelID: p.ChannelID, SenderID: p.SenderID, Body: p.Body}, true, nil
+		},
+	}
+	mem := &mockMembershipRepo{isMember: true, members: []uuid.UUID{sender, alice}}
+	pub := &mockPublisher{}
+	h := newTestHandler(repo, mem, pub)
+	r := setupRouter(h, sender, uuid.New())
+
+	body := `{"content":"hey <@` + alice.String() + `> and <@` + outsider.String() + `> and <@` + sender.String() + `>"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/messages", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	// Non-members and the sender are dropped.
+	if len(stored) != 1 || stored[0].UserID != alice || stored[0].Kind != models.MentionUser {
+		t.Fatalf("unexpected stored mentions: %+v", stored)
+	}
+	var notified bool
+	for _, p := range pub.published {
+		if p.channel == "user:"+alice.String() {
+			notified = true
+		}
+	}
+	if !notified {
+		t.Fatal("expected a mention event on alice's user topic")
+	}
+}
+
+func TestEdit_MentionsReplacedAndOnlyNewOnesNotified(t *testing.T) {
+	sender, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	repo := ownMessageRepo(sender)
+	var stored []models.Mention
+	repo.editFn = func(_ context.Context, _, channelID uuid.UUID, messageID int64, _ uuid.UUID, body string, mentions []models.Mention) (*models.Message, []models.Mention, error) {
+		stored = mentions
+		// alice was already mentioned by the original body.
+		var added []models.Mention
+		for _, m := range mentions {
+			if m.UserID != alice {
+				added = append(added, m)
+			}
+		}
+		return &models.Message{ID: messageID, ChannelID: channelID, SenderID: sender, Body: body}, added, nil
+	}
+	mem := &mockMembershipRepo{isMember: true, members: []uuid.UUID{sender, alice, bob}}
+	pub := &mockPublisher{}
+	h := newTestHandler(repo, mem, pub)
+	r := setupRouter(h, sender, uuid.New())
+
+	body := `{"content":"hey <@` + alice.String() + `> and <@` + bob.String() + `>"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/v1/channels/"+uuid.New().String()+"/messages/5", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(stored) != 2 {
+		t.Fatalf("expected both mentions stored, got %+v", stored)
+	}
+	notified := make(map[string]bool)
+	for _, p := range pub.published {
+		notified[p.channel] = true
+	}
+	if notified["user:"+alice.String()] {
+		t.Error("alice was already mentioned and should not be notified again")
+	}
+	if !notified["user:"+bob.String()] {
+		t.Error("expected a mention event on bob's user topic")
+	}
+}
+
+func TestMentions_Success(t *testing.T) {
+	uid := uuid.New()
+	var gotUser uuid.UUID
+	repo := &mockMessageRepo{
+		mentionFn: func(_ context.Context, _, userID uuid.UUID, _ int64, _ int) ([]models.MentionResult, error) {
+			gotUser = userID
+			return []models.MentionResult{{Message: models.Message{ID: 9}, ChannelName: "general", Kind: "here"}}, nil
+		},
+	}
+	h := newTestHandler(repo, nil, nil)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/users/me/mentions", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotUser != uid {
+		t.Fatalf("expected mentions for caller %s, got %s", uid, gotUser)
+	}
+	if !strings.Contains(w.Body.String(), `"mention_kind":"here"`) {
+		t.Fatalf("expected mention_kind in body: %s", w.Body.String())
+	}
+}
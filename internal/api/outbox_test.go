@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+// mockOutboxRepo is an in-memory repository.OutboxRepository.
+type mockOutboxRepo struct {
+	pending  []models.OutboxEvent
+	sent     []int64
+	failed   map[int64]time.Duration // id -> retryIn
+	released []int64
+}
+
+func newMockOutboxRepo() *mockOutboxRepo {
+	return &mockOutboxRepo{failed: make(map[int64]time.Duration)}
+}
+
+// insert stands in for the write MessageStore.Create does in its transaction.
+func (m *mockOutboxRepo) insert(events []models.OutboxEvent) {
+	for _, ev := range events {
+		ev.ID = int64(len(m.pending) + len(m.sent) + 1)
+		m.pending = append(m.pending, ev)
+	}
+}
+
+func (m *mockOutboxRepo) ClaimPending(_ context.Context, limit int, _ time.Duration) ([]models.OutboxEvent, error) {
+	n := min(limit, len(m.pending))
+	claimed := m.pending[:n]
+	m.pending = m.pending[n:]
+	return claimed, nil
+}
+
+func (m *mockOutboxRepo) MarkSent(_ context.Context, ids []int64) error {
+	m.sent = append(m.sent, ids...)
+	return nil
+}
+
+func (m *mockOutboxRepo) MarkFailed(_ context.Context, id int64, _ string, retryIn time.Duration) error {
+	m.failed[id] = retryIn
+	return nil
+}
+
+func (m *mockOutboxRepo) Release(_ context.Context, ids []int64) error {
+	m.released = append(m.released, ids...)
+	return nil
+}
+
+func (m *mockOutboxRepo) PruneSent(_ context.Context, _ time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// newTestOutboxHandler builds a MessageHandler whose service writes events
+// to outbox, and the relay that publishes them to pub.
+func newTestOutboxHandler(outbox *mockOutboxRepo, pub *mockPublisher) (*MessageHandler, *service.OutboxRelay) {
+	base := &mockMessageRepo{}
+	msgRepo := &mockMessageRepo{
+		createFn: func(ctx context.Context, p repository.CreateMessageParams) (*models.Message, bool, error) {
+			msg, created, err := base.Create(ctx, p)
+			if err != nil || !created || p.Outbox == nil {
+				return msg, created, err
+			}
+			events, err := p.Outbox(msg)
+			if err != nil {
+				return nil, false, err
+			}
+			outbox.insert(events)
+			return msg, true, nil
+		},
+	}
+	relay := service.NewOutboxRelay(outbox, pub, zap.NewNop())
+	svc := service.NewMessageService(msgRepo, &mockReactionRepo{}, &mockMembershipRepo{isMember: true}, &mockChannelRepo{}, &mockPinRepo{}, nil, nil, pub, relay, zap.NewNop())
+	return NewMessageHandler(svc, nil, zap.NewNop()), relay
+}
+
+func TestCreate_WithOutbox_PublishesThroughRelay(t *testing.T) {
+	uid, tid, chID := uuid.New(), uuid.New(), uuid.New()
+	outbox := newMockOutboxRepo()
+	pub := &mockPublisher{}
+	h, relay := newTestOutboxHandler(outbox, pub)
+	r := setupRouter(h, uid, tid)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/messages",
+		strings.NewReader(`{"content":"hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 0 {
+		t.Fatalf("expected no direct publish, got %d", len(pub.published))
+	}
+	if len(outbox.pending) != 1 || outbox.pending[0].Topic != "ch:"+chID.String() {
+		t.Fatalf("expected one outbox event for the channel, got %+v", outbox.pending)
+	}
+	select {
+	case <-relay.Woken():
+	default:
+		t.Fatal("expected the relay to be woken after the send")
+	}
+
+	n, err := relay.RelayPending(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 event relayed, got %d, %v", n, err)
+	}
+	if len(pub.published) != 1 || pub.published[0].channel != "ch:"+chID.String() {
+		t.Fatalf("expected the event on the channel topic, got %+v", pub.published)
+	}
+	var ev struct {
+		Type    string         `json:"type"`
+		Message models.Message `json:"message"`
+	}
+	if err := json.Unmarshal(pub.published[0].payload, &ev); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if ev.Type != "message" || ev.Message.Body != "hello" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if len(outbox.sent) != 1 || outbox.sent[0] != 1 {
+		t.Fatalf("expected event 1 marked sent, got %v", outbox.sent)
+	}
+}
+
+func TestOutboxRelay_FailedPublishIsRetriedWithBackoff(t *testing.T) {
+	outbox := newMockOutboxRepo()
+	outbox.pending = []models.OutboxEvent{
+		{ID: 1, Topic: "ch:a", Payload: []byte(`{}`), Attempts: 3},
+		{ID: 2, Topic: "ch:b", Payload: []byte(`{}`)},
+		{ID: 3, Topic: "ch:c", Payload: []byte(`{}`)},
+	}
+	pub := &mockPublisher{err: context.DeadlineExceeded}
+	relay := service.NewOutboxRelay(outbox, pub, zap.NewNop())
+
+	n, err := relay.RelayPending(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("expected nothing relayed, got %d, %v", n, err)
+	}
+	if len(pub.published) != 1 {
+		t.Fatalf("expected the batch to stop after the first failure, got %d publishes", len(pub.published))
+	}
+	if len(outbox.sent) != 0 {
+		t.Fatalf("expected nothing marked sent, got %v", outbox.sent)
+	}
+	if len(outbox.failed) != 1 || outbox.failed[1] != 8*time.Second {
+		t.Fatalf("expected event 1 retried in 8s, got %v", outbox.failed)
+	}
+	if len(outbox.released) != 2 || outbox.released[0] != 2 || outbox.released[1] != 3 {
+		t.Fatalf("expected events 2 and 3 released, got %v", outbox.released)
+	}
+}
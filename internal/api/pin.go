@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+// AddPin handles POST /v1/channels/:id/pins/:msgID
+func (h *MessageHandler) AddPin(c *gin.Context) {
+	h.pin(c, h.svc.Pin, "failed to pin message")
+}
+
+// RemovePin handles DELETE /v1/channels/:id/pins/:msgID
+func (h *MessageHandler) RemovePin(c *gin.Context) {
+	h.pin(c, h.svc.Unpin, "failed to unpin message")
+}
+
+type pinFunc func(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, userID uuid.UUID) error
+
+// pin parses the shared path params and maps service errors for both pin
+// endpoints. Both are idempotent and return 204.
+func (h *MessageHandler) pin(c *gin.Context, fn pinFunc, failMsg string) {
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return
+	}
+
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	err = fn(c.Request.Context(), tenantID, channelID, messageID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMessageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotMember), errors.Is(err, service.ErrPinAdminOnly):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrChannelArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error(failMsg, zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": failMsg})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListPins handles GET /v1/channels/:id/pins
+func (h *MessageHandler) ListPins(c *gin.Context) {
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	pins, err := h.svc.ListPins(c.Request.Context(), tenantID, channelID, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrNotMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to list pins", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list pins"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pins)
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+// mockPinRepo implements repository.PinRepository.
+type mockPinRepo struct {
+	changed bool // returned by Pin and Unpin
+	pins    []models.PinnedMessage
+}
+
+func (m *mockPinRepo) Pin(_ context.Context, _ uuid.UUID, _ int64, _ uuid.UUID) (bool, error) {
+	return m.changed, nil
+}
+func (m *mockPinRepo) Unpin(_ context.Context, _ uuid.UUID, _ int64) (bool, error) {
+	return m.changed, nil
+}
+func (m *mockPinRepo) List(_ context.Context, _, _ uuid.UUID) ([]models.PinnedMessage, error) {
+	return m.pins, nil
+}
+
+func newTestPinHandler(msgRepo *mockMessageRepo, memRepo *mockMembershipRepo, chRepo *mockChannelRepo, pins *mockPinRepo, pub *mockPublisher) *MessageHandler {
+	var publisher service.EventPublisher
+	if pub != nil {
+		publisher = pub
+	}
+	svc := service.NewMessageService(msgRepo, &mockReactionRepo{}, memRepo, chRepo, pins, nil, nil, publisher, nil, zap.NewNop())
+	return NewMessageHandler(svc, nil, zap.NewNop())
+}
+
+func adminOnlyChannels() *mockChannelRepo {
+	return &mockChannelRepo{
+		getByIDFn: func(_ context.Context, tenantID, channelID uuid.UUID) (*models.Channel, error) {
+			return &models.Channel{ID: channelID, TenantID: tenantID, PinsAdminOnly: true}, nil
+		},
+	}
+}
+
+func TestAddPin_PublishesEvent(t *testing.T) {
+	uid, chID := uuid.New(), uuid.New()
+	pub := &mockPublisher{}
+	h := newTestPinHandler(ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true}, &mockChannelRepo{}, &mockPinRepo{changed: true}, pub)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/pins/7", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 1 || pub.published[0].channel != "ch:"+chID.String() {
+		t.Fatalf("expected 1 publish to the channel, got %+v", pub.published)
+	}
+	var ev struct {
+		Type      string `json:"type"`
+		MessageID int64  `json:"message_id"`
+		UserID    string `json:"user_id"`
+	}
+	if err := json.Unmarshal(pub.published[0].payload, &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Type != "pin_added" || ev.MessageID != 7 || ev.UserID != uid.String() {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
+
+func TestAddPin_AlreadyPinnedDoesNotPublish(t *testing.T) {
+	pub := &mockPublisher{}
+	h := newTestPinHandler(ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true}, &mockChannelRepo{}, &mockPinRepo{changed: false}, pub)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/pins/7", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 0 {
+		t.Fatalf("expected no publish, got %d", len(pub.published))
+	}
+}
+
+func TestPin_Permissions(t *testing.T) {
+	deleted := &mockMessageRepo{
+		getFn: func(_ context.Context, _, channelID uuid.UUID, messageID int64) (*models.Message, error) {
+			now := time.Now()
+			return &models.Message{ID: messageID, ChannelID: channelID, DeletedAt: &now}, nil
+		},
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		msgRepo *mockMessageRepo
+		memRepo *mockMembershipRepo
+		chRepo  *mockChannelRepo
+		want    int
+	}{
+		{"non-member", "POST", ownMessageRepo(uuid.New()), &mockMembershipRepo{}, &mockChannelRepo{}, http.StatusForbidden},
+		{"member in admin-only channel", "POST", ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true}, adminOnlyChannels(), http.StatusForbidden},
+		{"member unpin in admin-only channel", "DELETE", ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true}, adminOnlyChannels(), http.StatusForbidden},
+		{"admin in admin-only channel", "POST", ownMessageRepo(uuid.New()), &mockMembershipRepo{isMember: true, role: "admin"}, adminOnlyChannels(), http.StatusNoContent},
+		{"deleted message", "POST", deleted, &mockMembershipRepo{isMember: true}, &mockChannelRepo{}, http.StatusNotFound},
+		{"missing message", "POST", &mockMessageRepo{}, &mockMembershipRepo{isMember: true}, &mockChannelRepo{}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestPinHandler(tt.msgRepo, tt.memRepo, tt.chRepo, &mockPinRepo{changed: true}, &mockPublisher{})
+			r := setupRouter(h, uuid.New(), uuid.New())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, "/v1/channels/"+uuid.New().String()+"/pins/7", nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListPins(t *testing.T) {
+	pinner := uuid.New()
+	pins := &mockPinRepo{pins: []models.PinnedMessage{
+		{Message: models.Message{ID: 9, Body: "deploy checklist"}, PinnedBy: pinner, PinnedAt: time.Now()},
+	}}
+
+	h := newTestPinHandler(&mockMessageRepo{}, &mockMembershipRepo{isMember: true}, &mockChannelRepo{}, pins, nil)
+	r := setupRouter(h, uuid.New(), uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/channels/"+uuid.New().String()+"/pins", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got []models.PinnedMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != 9 || got[0].PinnedBy != pinner {
+		t.Fatalf("unexpected pins: %+v", got)
+	}
+
+	h = newTestPinHandler(&mockMessageRepo{}, &mockMembershipRepo{}, &mockChannelRepo{}, pins, nil)
+	r = setupRouter(h, uuid.New(), uuid.New())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-member, got %d", w.Code)
+	}
+}
+
+func TestDelete_PinnedMessagePublishesPinRemoved(t *testing.T) {
+	uid := uuid.New()
+	msgRepo := ownMessageRepo(uid)
+	msgRepo.deleteFn = func(_ context.Context, _, channelID uuid.UUID, messageID int64, deletedBy uuid.UUID) (*models.Message, bool, error) {
+		now := time.Now()
+		return &models.Message{ID: messageID, ChannelID: channelID, DeletedAt: &now, DeletedBy: &deletedBy}, true, nil
+	}
+	pub := &mockPublisher{}
+	h := newTestPinHandler(msgRepo, &mockMembershipRepo{isMember: true}, &mockChannelRepo{}, &mockPinRepo{}, pub)
+	r := setupRouter(h, uid, uuid.New())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/v1/channels/"+uuid.New().String()+"/messages/7", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(pub.published) != 2 {
+		t.Fatalf("expected message_deleted + pin_removed, got %d events", len(pub.published))
+	}
+	var ev struct {
+		Type      string `json:"type"`
+		MessageID int64  `json:"message_id"`
+	}
+	json.Unmarshal(pub.published[1].payload, &ev)
+	if ev.Type != "pin_removed" || ev.MessageID != 7 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
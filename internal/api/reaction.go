@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+// AddReaction handles POST /v1/channels/:id/messages/:msgID/reactions/:emoji
+func (h *MessageHandler) AddReaction(c *gin.Context) {
+	h.react(c, h.svc.AddReaction, "failed to add reaction")
+}
+
+// RemoveReaction handles DELETE /v1/channels/:id/messages/:msgID/reactions/:emoji
+func (h *MessageHandler) RemoveReaction(c *gin.Context) {
+	h.react(c, h.svc.RemoveReaction, "failed to remove reaction")
+}
+
+type reactionFunc func(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, userID uuid.UUID, emoji string) error
+
+// react parses the shared path params and maps service errors for both
+// reaction endpoints. Both are idempotent and return 204.
+func (h *MessageHandler) react(c *gin.Context, fn reactionFunc, failMsg string) {
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return
+	}
+
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	err = fn(c.Request.Context(), tenantID, channelID, messageID, userID, c.Param("emoji"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidEmoji):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrMessageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotMember):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrChannelArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error(failMsg, zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": failMsg})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+type markReadRequest struct {
+	MessageID int64 `json:"message_id" binding:"required,gt=0"`
+}
+
+// MarkRead handles POST /v1/channels/:id/read
+//
+// Moves the caller's read cursor to message_id. Older cursors are
+// ignored, so clients can fire this freely; it always returns 204.
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	channelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel ID"})
+		return
+	}
+
+	var req markReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	err = h.svc.MarkRead(c.Request.Context(), tenantID, channelID, userID, req.MessageID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMessageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotMember):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to mark channel read", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark channel read"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Unreads handles GET /v1/users/me/unreads
+func (h *MessageHandler) Unreads(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	unreads, err := h.svc.Unreads(c.Request.Context(), tenantID, userID)
+	if err != nil {
+		h.logger.Error("failed to list unreads", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list unreads"})
+		return
+	}
+
+	c.JSON(http.StatusOK, unreads)
+}
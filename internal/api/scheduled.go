@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+type updateScheduledRequest struct {
+	Content *string    `json:"content"`
+	SendAt  *time.Time `json:"send_at"`
+}
+
+// schedule is the send_at branch of Create. It answers 202 with the
+// scheduled message; the real message is created when it's delivered.
+func (h *MessageHandler) schedule(c *gin.Context, tenantID, channelID, userID uuid.UUID, body string, sendAt time.Time, opts service.SendOptions) {
+	if !h.schedulingEnabled(c) {
+		return
+	}
+
+	m, created, err := h.scheduler.Schedule(c.Request.Context(), tenantID, channelID, userID, body, sendAt, opts)
+	if err != nil {
+		h.scheduledError(c, err, "failed to schedule message")
+		return
+	}
+
+	if !created {
+		// Idempotent retry: the message was already scheduled.
+		c.JSON(http.StatusOK, m)
+		return
+	}
+	c.JSON(http.StatusAccepted, m)
+}
+
+// ListScheduled handles GET /v1/scheduled-messages
+func (h *MessageHandler) ListScheduled(c *gin.Context) {
+	if !h.schedulingEnabled(c) {
+		return
+	}
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	list, err := h.scheduler.List(c.Request.Context(), tenantID, userID)
+	if err != nil {
+		h.logger.Error("failed to list scheduled messages", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list scheduled messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// UpdateScheduled handles PATCH /v1/scheduled-messages/:id
+func (h *MessageHandler) UpdateScheduled(c *gin.Context) {
+	if !h.schedulingEnabled(c) {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduled message ID"})
+		return
+	}
+
+	var req updateScheduledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	m, err := h.scheduler.Update(c.Request.Context(), tenantID, userID, id, service.ScheduledUpdate{
+		Body:   req.Content,
+		SendAt: req.SendAt,
+	})
+	if err != nil {
+		h.scheduledError(c, err, "failed to update scheduled message")
+		return
+	}
+
+	c.JSON(http.StatusOK, m)
+}
+
+// CancelScheduled handles DELETE /v1/scheduled-messages/:id
+func (h *MessageHandler) CancelScheduled(c *gin.Context) {
+	if !h.schedulingEnabled(c) {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduled message ID"})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	if err := h.scheduler.Cancel(c.Request.Context(), tenantID, userID, id); err != nil {
+		h.scheduledError(c, err, "failed to cancel scheduled message")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// schedulingEnabled reports whether the handler has a scheduler.
+// If not, it writes the HTTP response and returns false.
+func (h *MessageHandler) schedulingEnabled(c *gin.Context) bool {
+	if h.scheduler == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scheduled messages are not enabled"})
+		return false
+	}
+	return true
+}
+
+func (h *MessageHandler) scheduledError(c *gin.Context, err error, failMsg string) {
+	switch {
+	case errors.Is(err, service.ErrEmptyBody), errors.Is(err, service.ErrBodyTooLong),
+		errors.Is(err, service.ErrTooManyAttachments), errors.Is(err, service.ErrInvalidAttachment),
+		errors.Is(err, service.ErrInvalidParent), errors.Is(err, service.ErrClientMsgIDTooLong),
+		errors.Is(err, service.ErrSendAtInPast), errors.Is(err, service.ErrSendAtTooFar):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrScheduledNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrScheduledNotPending), errors.Is(err, service.ErrChannelArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(failMsg, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failMsg})
+	}
+}
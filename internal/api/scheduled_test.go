@@ -0,0 +1,360 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+// mockScheduledRepo is an in-memory repository.ScheduledMessageRepository.
+type mockScheduledRepo struct {
+	items  map[uuid.UUID]*models.ScheduledMessage
+	due    []models.ScheduledMessage // returned once by ClaimDue
+	sent   map[uuid.UUID]int64
+	failed map[uuid.UUID]string
+}
+
+func newMockScheduledRepo() *mockScheduledRepo {
+	return &mockScheduledRepo{
+		items:  make(map[uuid.UUID]*models.ScheduledMessage),
+		sent:   make(map[uuid.UUID]int64),
+		failed: make(map[uuid.UUID]string),
+	}
+}
+
+func (m *mockScheduledRepo) Create(_ context.Context, sm *models.ScheduledMessage) (*models.ScheduledMessage, bool, error) {
+	if sm.ClientMsgID != nil {
+		for _, existing := range m.items {
+			if existing.SenderID == sm.SenderID && existing.ChannelID == sm.ChannelID &&
+				existing.ClientMsgID != nil && *existing.ClientMsgID == *sm.ClientMsgID {
+				out := *existing
+				return &out, false, nil
+			}
+		}
+	}
+	out := *sm
+	out.ID = uuid.New()
+	out.Status = models.ScheduledPending
+	m.items[out.ID] = &out
+	return &out, true, nil
+}
+
+func (m *mockScheduledRepo) GetByID(_ context.Context, tenantID, senderID, id uuid.UUID) (*models.ScheduledMessage, error) {
+	sm, ok := m.items[id]
+	if !ok || sm.TenantID != tenantID || sm.SenderID != senderID {
+		return nil, nil
+	}
+	out := *sm
+	return &out, nil
+}
+
+func (m *mockScheduledRepo) ListBySender(_ context.Context, tenantID, senderID uuid.UUID) ([]models.ScheduledMessage, error) {
+	out := make([]models.ScheduledMessage, 0)
+	for _, sm := range m.items {
+		if sm.TenantID == tenantID && sm.SenderID == senderID {
+			out = append(out, *sm)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockScheduledRepo) Update(ctx context.Context, tenantID, senderID, id uuid.UUID, body string, sendAt time.Time) (*models.ScheduledMessage, error) {
+	sm, _ := m.GetByID(ctx, tenantID, senderID, id)
+	if sm == nil || sm.Status != models.ScheduledPending {
+		return nil, nil
+	}
+	sm.Body, sm.SendAt = body, sendAt
+	m.items[id] = sm
+	return sm, nil
+}
+
+func (m *mockScheduledRepo) Delete(ctx context.Context, tenantID, senderID, id uuid.UUID) (bool, error) {
+	sm, _ := m.GetByID(ctx, tenantID, senderID, id)
+	if sm == nil {
+		return false, nil
+	}
+	delete(m.items, id)
+	return true, nil
+}
+
+func (m *mockScheduledRepo) ClaimDue(_ context.Context, _ int, _ time.Duration) ([]models.ScheduledMessage, error) {
+	due := m.due
+	m.due = nil
+	return due, nil
+}
+
+func (m *mockScheduledRepo) MarkSent(_ context.Context, id uuid.UUID, messageID int64) error {
+	m.sent[id] = messageID
+	return nil
+}
+
+func (m *mockScheduledRepo) MarkFailed(_ context.Context, id uuid.UUID, reason string) error {
+	m.failed[id] = reason
+	return nil
+}
+
+func newTestScheduleHandler(msgRepo *mockMessageRepo, memRepo *mockMembershipRepo, sched *mockScheduledRepo, pub *mockPublisher) (*MessageHandler, *service.ScheduleService) {
+	var publisher service.EventPublisher
+	if pub != nil {
+		publisher = pub
+	}
+	msgSvc := service.NewMessageService(msgRepo, &mockReactionRepo{}, memRepo, &mockChannelRepo{}, &mockPinRepo{}, nil, nil, publisher, nil, zap.NewNop())
+	schedSvc := service.NewScheduleService(sched, msgSvc, zap.NewNop())
+	return NewMessageHandler(msgSvc, schedSvc, zap.NewNop()), schedSvc
+}
+
+func scheduleRouter(h *MessageHandler, uid, tid uuid.UUID) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, uid)
+		c.Set(middleware.ContextKeyTenantID, tid)
+		c.Next()
+	})
+	r.POST("/v1/channels/:id/messages", h.Create)
+	r.GET("/v1/scheduled-messages", h.ListScheduled)
+	r.PATCH("/v1/scheduled-messages/:id", h.UpdateScheduled)
+	r.DELETE("/v1/scheduled-messages/:id", h.CancelScheduled)
+	return r
+}
+
+func TestCreate_Scheduled(t *testing.T) {
+	uid, tid, chID := uuid.New(), uuid.New(), uuid.New()
+	sched := newMockScheduledRepo()
+	pub := &mockPublisher{}
+	h, _ := newTestScheduleHandler(&mockMessageRepo{}, &mockMembershipRepo{isMember: true}, sched, pub)
+	r := scheduleRouter(h, uid, tid)
+
+	sendAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/messages",
+		strings.NewReader(`{"content":"standup in 5","send_at":"`+sendAt+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var got models.ScheduledMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Status != models.ScheduledPending || got.Body != "standup in 5" || got.ChannelID != chID {
+		t.Fatalf("unexpected scheduled message: %+v", got)
+	}
+	if len(sched.items) != 1 {
+		t.Fatalf("expected 1 stored, got %d", len(sched.items))
+	}
+	if len(pub.published) != 0 {
+		t.Fatalf("nothing should be published until delivery, got %d", len(pub.published))
+	}
+}
+
+func TestCreate_ScheduledRejects(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name     string
+		body     string
+		isMember bool
+		want     int
+	}{
+		{"in the past", `{"content":"hi","send_at":"2001-01-01T00:00:00Z"}`, true, http.StatusBadRequest},
+		{"too far ahead", `{"content":"hi","send_at":"` + time.Now().AddDate(1, 0, 0).UTC().Format(time.RFC3339) + `"}`, true, http.StatusBadRequest},
+		{"missing thread parent", `{"content":"hi","parent_id":7,"send_at":"` + future + `"}`, true, http.StatusBadRequest},
+		{"unknown attachment", `{"content":"hi","attachment_ids":["` + uuid.New().String() + `"],"send_at":"` + future + `"}`, true, http.StatusBadRequest},
+		{"not a member", `{"content":"hi","send_at":"` + future + `"}`, false, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestScheduleHandler(&mockMessageRepo{}, &mockMembershipRepo{isMember: tt.isMember}, newMockScheduledRepo(), nil)
+			r := scheduleRouter(h, uuid.New(), uuid.New())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/channels/"+uuid.New().String()+"/messages", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreate_ScheduledRetryReturnsOriginal(t *testing.T) {
+	uid, chID := uuid.New(), uuid.New()
+	sched := newMockScheduledRepo()
+	h, _ := newTestScheduleHandler(&mockMessageRepo{}, &mockMembershipRepo{isMember: true}, sched, nil)
+	r := scheduleRouter(h, uid, uuid.New())
+
+	sendAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/channels/"+chID.String()+"/messages",
+			strings.NewReader(`{"content":"standup in 5","send_at":"`+sendAt+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "sched-1")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := post()
+	if first.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", first.Code, first.Body.String())
+	}
+	retry := post()
+	if retry.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a retry, got %d: %s", retry.Code, retry.Body.String())
+	}
+	var a, b models.ScheduledMessage
+	if err := json.Unmarshal(first.Body.Bytes(), &a); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if err := json.Unmarshal(retry.Body.Bytes(), &b); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if a.ID != b.ID || len(sched.items) != 1 {
+		t.Fatalf("expected the retry to return %s with 1 row stored, got %s and %d rows", a.ID, b.ID, len(sched.items))
+	}
+}
+
+func TestUpdateAndCancelScheduled(t *testing.T) {
+	uid, tid := uuid.New(), uuid.New()
+	sched := newMockScheduledRepo()
+	pending := &models.ScheduledMessage{ID: uuid.New(), TenantID: tid, SenderID: uid, Body: "old", SendAt: time.Now().Add(time.Hour), Status: models.ScheduledPending}
+	failed := &models.ScheduledMessage{ID: uuid.New(), TenantID: tid, SenderID: uid, Body: "x", Status: models.ScheduledFailed}
+	others := &models.ScheduledMessage{ID: uuid.New(), TenantID: tid, SenderID: uuid.New(), Body: "y", Status: models.ScheduledPending}
+	for _, sm := range []*models.ScheduledMessage{pending, failed, others} {
+		sched.items[sm.ID] = sm
+	}
+	h, _ := newTestScheduleHandler(&mockMessageRepo{}, &mockMembershipRepo{isMember: true}, sched, nil)
+	r := scheduleRouter(h, uid, tid)
+
+	do := func(method string, id uuid.UUID, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/v1/scheduled-messages/"+id.String(), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("PATCH", pending.ID, `{"content":"new"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if sched.items[pending.ID].Body != "new" {
+		t.Fatalf("body not updated: %q", sched.items[pending.ID].Body)
+	}
+	if w := do("PATCH", failed.ID, `{"content":"new"}`); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 editing a failed message, got %d", w.Code)
+	}
+	if w := do("PATCH", others.ID, `{"content":"new"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for someone else's message, got %d", w.Code)
+	}
+	if w := do("DELETE", pending.ID, ``); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("DELETE", pending.ID, ``); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after cancel, got %d", w.Code)
+	}
+}
+
+func TestDeliverDue(t *testing.T) {
+	tid, chID := uuid.New(), uuid.New()
+	ok := models.ScheduledMessage{ID: uuid.New(), TenantID: tid, ChannelID: chID, SenderID: uuid.New(), Body: "hello"}
+	sched := newMockScheduledRepo()
+	sched.due = []models.ScheduledMessage{ok}
+
+	var clientMsgID string
+	msgRepo := &mockMessageRepo{
+		createFn: func(_ context.Context, p repository.CreateMessageParams) (*models.Message, bool, error) {
+			clientMsgID = p.ClientMsgID
+			return &models.Message{ID: 42, ChannelID: p.ChannelID, SenderID: p.SenderID, Body: p.Body}, true, nil
+		},
+	}
+	pub := &mockPublisher{}
+	_, svc := newTestScheduleHandler(msgRepo, &mockMembershipRepo{isMember: true}, sched, pub)
+
+	n, err := svc.DeliverDue(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("DeliverDue = %d, %v", n, err)
+	}
+	if sched.sent[ok.ID] != 42 {
+		t.Fatalf("expected marked sent with message 42, got %v", sched.sent)
+	}
+	if clientMsgID != "scheduled:"+ok.ID.String() {
+		t.Fatalf("delivery must be idempotent, client_msg_id = %q", clientMsgID)
+	}
+	if len(pub.published) != 1 || pub.published[0].channel != "ch:"+chID.String() {
+		t.Fatalf("expected the message to be published to the channel, got %+v", pub.published)
+	}
+}
+
+func TestDeliverDue_RechecksMembership(t *testing.T) {
+	sm := models.ScheduledMessage{ID: uuid.New(), TenantID: uuid.New(), ChannelID: uuid.New(), SenderID: uuid.New(), Body: "hello"}
+	sched := newMockScheduledRepo()
+	sched.due = []models.ScheduledMessage{sm}
+	pub := &mockPublisher{}
+	// The sender left the channel after scheduling.
+	_, svc := newTestScheduleHandler(&mockMessageRepo{}, &mockMembershipRepo{isMember: false}, sched, pub)
+
+	if _, err := svc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+	if _, ok := sched.failed[sm.ID]; !ok {
+		t.Fatalf("expected the message to be marked failed")
+	}
+	if len(pub.published) != 1 || pub.published[0].channel != "user:"+sm.SenderID.String() {
+		t.Fatalf("expected a failure notice to the sender, got %+v", pub.published)
+	}
+}
+
+func TestDeliverDue_TransientErrorRetries(t *testing.T) {
+	sm := models.ScheduledMessage{ID: uuid.New(), TenantID: uuid.New(), ChannelID: uuid.New(), SenderID: uuid.New(), Body: "hello"}
+	sched := newMockScheduledRepo()
+	sched.due = []models.ScheduledMessage{sm}
+	msgRepo := &mockMessageRepo{
+		createFn: func(context.Context, repository.CreateMessageParams) (*models.Message, bool, error) {
+			return nil, false, errors.New("db down")
+		},
+	}
+	_, svc := newTestScheduleHandler(msgRepo, &mockMembershipRepo{isMember: true}, sched, nil)
+
+	if _, err := svc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+	if len(sched.sent) != 0 || len(sched.failed) != 0 {
+		t.Fatalf("a transient failure must leave the message pending, sent=%v failed=%v", sched.sent, sched.failed)
+	}
+}
+
+func TestScheduledRoutes_DisabledWithoutScheduler(t *testing.T) {
+	h := newTestHandler(nil, nil, nil) // no ScheduleService
+	r := scheduleRouter(h, uuid.New(), uuid.New())
+	id := uuid.NewString()
+
+	for _, tc := range []struct{ method, path, body string }{
+		{"GET", "/v1/scheduled-messages", ""},
+		{"PATCH", "/v1/scheduled-messages/" + id, `{"content":"later"}`},
+		{"DELETE", "/v1/scheduled-messages/" + id, ""},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: expected 400, got %d: %s", tc.method, tc.path, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lalith-99/echostream/internal/middleware"
+	"github.com/lalith-99/echostream/internal/service"
+	"go.uber.org/zap"
+)
+
+// Search handles GET /v1/search/messages?q=...&before=123&limit=50
+//
+// See service.MessageService.Search for the query syntax. Paginate by
+// passing the smallest returned id as ?before=.
+func (h *MessageHandler) Search(c *gin.Context) {
+	before, limit, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	tenantID := middleware.GetTenantID(c)
+
+	results, err := h.svc.Search(c.Request.Context(), tenantID, userID, c.Query("q"), before, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptySearch), errors.Is(err, service.ErrSearchTooLong),
+			errors.Is(err, service.ErrInvalidSearchDate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to search messages", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search messages"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -1,12 +1,17 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	gorillaws "github.com/gorilla/websocket"
 	"github.com/lalith-99/echostream/internal/auth"
+	"github.com/lalith-99/echostream/internal/models"
 	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/service"
 	"github.com/lalith-99/echostream/internal/websocket"
 	"go.uber.org/zap"
 )
@@ -39,13 +44,15 @@ var upgrader = gorillaws.Upgrader{
 type WSHandler struct {
 	hub            *websocket.Hub
 	membershipRepo repository.MembershipRepository
+	messages       *service.MessageService
 	jwtSecret      string
 	logger         *zap.Logger
 }
 
-// NewWSHandler creates a WebSocket handler.
-func NewWSHandler(hub *websocket.Hub, membershipRepo repository.MembershipRepository, jwtSecret string, logger *zap.Logger) *WSHandler {
-	return &WSHandler{hub: hub, membershipRepo: membershipRepo, jwtSecret: jwtSecret, logger: logger}
+// NewWSHandler creates a WebSocket handler. messages backs replay for
+// clients that subscribe with a last_seen_id.
+func NewWSHandler(hub *websocket.Hub, membershipRepo repository.MembershipRepository, messages *service.MessageService, jwtSecret string, logger *zap.Logger) *WSHandler {
+	return &WSHandler{hub: hub, membershipRepo: membershipRepo, messages: messages, jwtSecret: jwtSecret, logger: logger}
 }
 
 // HandleWS upgrades HTTP to WebSocket. Auth via ?token=<jwt> query param
@@ -69,9 +76,42 @@ func (h *WSHandler) HandleWS(c *gin.Context) {
 		return
 	}
 
-	client := websocket.NewClient(h.hub, conn, claims.UserID, claims.TenantID, h.membershipRepo.IsMember, h.logger)
+	client := websocket.NewClient(h.hub, conn, claims.UserID, claims.TenantID,
+		h.membershipRepo.IsMember, h.messages.ListAfter, h.sendMessage, h.logger)
 	h.hub.Register(client)
 
 	go client.WritePump()
 	go client.ReadPump()
 }
+
+// wsSendErrorCodes maps service rule violations to the codes clients see
+// in a websocket send error.
+var wsSendErrorCodes = []struct {
+	err  error
+	code string
+}{
+	{service.ErrEmptyBody, "empty_body"},
+	{service.ErrBodyTooLong, "body_too_long"},
+	{service.ErrNotMember, "not_member"},
+	{service.ErrInvalidParent, "invalid_parent"},
+	{service.ErrClientMsgIDTooLong, "invalid_client_msg_id"},
+	{service.ErrChannelArchived, "channel_archived"},
+}
+
+// sendMessage adapts MessageService.Send for websocket "send" frames, so
+// they follow exactly the same rules as POST /channels/:id/messages.
+func (h *WSHandler) sendMessage(ctx context.Context, tenantID, channelID, senderID uuid.UUID, body string, parentID int64, clientMsgID string) (*models.Message, error) {
+	msg, _, err := h.messages.Send(ctx, tenantID, channelID, senderID, body, service.SendOptions{
+		ParentID:    parentID,
+		ClientMsgID: clientMsgID,
+	})
+	if err != nil {
+		for _, m := range wsSendErrorCodes {
+			if errors.Is(err, m.err) {
+				return nil, &websocket.SendError{Code: m.code, Message: err.Error()}
+			}
+		}
+		return nil, err
+	}
+	return msg, nil
+}
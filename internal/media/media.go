@@ -0,0 +1,117 @@
+// Package media derives previews from uploaded images: downscaled
+// thumbnails and blurhash placeholders. It uses only the standard
+// library image packages, so PNG, JPEG and GIF (first frame) are supported.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif" // register decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// MaxPixels caps the decoded size of an image. A small, highly compressed
+// file can claim huge dimensions; DecodeConfig lets us refuse it before
+// allocating the pixel buffer.
+const MaxPixels = 40_000_000
+
+var ErrTooLarge = errors.New("image dimensions exceed the processing limit")
+
+// Decode reads an image after checking its dimensions against MaxPixels.
+func Decode(r io.Reader) (image.Image, error) {
+	// Whatever DecodeConfig consumes is replayed for the full decode.
+	var head bytes.Buffer
+	cfg, _, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(io.MultiReader(&head, r))
+	return img, err
+}
+
+// Fit returns the size of w×h scaled down so its longest edge is box,
+// keeping the aspect ratio. Images already within box are unchanged.
+func Fit(w, h, box int) (int, int) {
+	if w <= box && h <= box {
+		return w, h
+	}
+	if w >= h {
+		return box, max(1, (h*box+w/2)/w)
+	}
+	return max(1, (w*box+h/2)/h), box
+}
+
+// Resize scales src down to fit within box×box using area averaging,
+// which is cheap and avoids the aliasing of nearest-neighbour sampling.
+// It never scales up. Convert the source with ToRGBA once and resize that
+// for every size, rather than paying for a full-size copy each time.
+func Resize(src *image.RGBA, box int) *image.RGBA {
+	src = ToRGBA(src) // no-op unless src has a non-zero origin
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := Fit(sw, sh, box)
+	if dw == sw && dh == sh {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, (dy+1)*sh/dh
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, (dx+1)*sw/dw
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride+x0*4 : y*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					b += uint64(row[i+2])
+					a += uint64(row[i+3])
+					n++
+				}
+			}
+			o := dy*dst.Stride + dx*4
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(b / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// Encode writes img as JPEG or PNG. JPEG is used only for JPEG sources;
+// everything else keeps PNG so transparency survives.
+func Encode(w io.Writer, img image.Image, contentType string) error {
+	if contentType == "image/jpeg" {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 80})
+	}
+	return png.Encode(w, img)
+}
+
+// ThumbnailContentType is the format Encode produces for a source type.
+func ThumbnailContentType(contentType string) string {
+	if contentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// ToRGBA returns img as a zero-origin *image.RGBA (premultiplied alpha,
+// so averaging doesn't bleed colour out of transparent pixels). An image
+// that already is one is returned as-is; anything else is copied.
+func ToRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,113 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		w, h, box    int
+		wantW, wantH int
+	}{
+		{1920, 1080, 720, 720, 405},
+		{1080, 1920, 360, 203, 360},
+		{500, 500, 64, 64, 64},
+		{100, 50, 720, 100, 50}, // never scales up
+		{4000, 1, 64, 64, 1},    // keeps at least one pixel
+	}
+	for _, tt := range tests {
+		w, h := Fit(tt.w, tt.h, tt.box)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("Fit(%d, %d, %d) = %dx%d, want %dx%d", tt.w, tt.h, tt.box, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestResize_AveragesPixels(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.White)
+	src.Set(1, 1, color.White)
+	src.Set(1, 0, color.Black)
+	src.Set(0, 1, color.Black)
+
+	dst := Resize(src, 1)
+	if dst.Rect.Dx() != 1 || dst.Rect.Dy() != 1 {
+		t.Fatalf("unexpected size %v", dst.Rect)
+	}
+	if got := dst.RGBAAt(0, 0); got != (color.RGBA{127, 127, 127, 255}) {
+		t.Fatalf("expected mid grey, got %v", got)
+	}
+}
+
+func TestBlurhash(t *testing.T) {
+	tests := []struct {
+		name   string
+		colour color.RGBA
+		dc     string // base83 of the average colour, 0xRRGGBB
+	}{
+		{"white", color.RGBA{255, 255, 255, 255}, encode83(0xFFFFFF, 4)},
+		{"red", color.RGBA{255, 0, 0, 255}, encode83(0xFF0000, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, 50, 40))
+			for y := 0; y < 40; y++ {
+				for x := 0; x < 50; x++ {
+					img.SetRGBA(x, y, tt.colour)
+				}
+			}
+
+			got := Blurhash(img)
+			// size flag + max AC + 4-char DC + 11 two-char AC components
+			if len(got) != 28 {
+				t.Fatalf("expected 28 chars, got %d (%q)", len(got), got)
+			}
+			if got[0] != 'L' {
+				t.Fatalf("expected 4x3 size flag 'L', got %q", got[0])
+			}
+			if got[2:6] != tt.dc {
+				t.Fatalf("DC = %q, want %q", got[2:6], tt.dc)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2)))
+	img, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if img.Bounds().Dx() != 3 || img.Bounds().Dy() != 2 {
+		t.Fatalf("unexpected bounds %v", img.Bounds())
+	}
+
+	if _, err := Decode(bytes.NewReader(pngHeader(100_000, 100_000))); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+// pngHeader builds just a PNG signature and IHDR chunk claiming w×h.
+func pngHeader(w, h uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], w)
+	binary.BigEndian.PutUint32(ihdr[4:], h)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // RGBA
+
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&b, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	b.Write(chunk)
+	binary.Write(&b, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return b.Bytes()
+}
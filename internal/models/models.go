@@ -23,13 +23,39 @@ type User struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// Channel kinds. DMs are modelled as channels so messaging, membership and
+// websocket subscriptions need no special cases.
+const (
+	ChannelKindChannel = "channel"
+	ChannelKindDM      = "dm"       // exactly two participants
+	ChannelKindGroupDM = "group_dm" // three or more participants
+)
+
 // Channel is a chat room within a tenant.
 type Channel struct {
 	ID        uuid.UUID `json:"id"`
 	TenantID  uuid.UUID `json:"tenant_id"`
 	Name      string    `json:"name"`
 	IsPrivate bool      `json:"is_private"`
+	Kind      string    `json:"kind"`
 	CreatedAt time.Time `json:"created_at"`
+
+	PinsAdminOnly bool `json:"pins_admin_only"` // only admins may pin/unpin
+
+	Topic      string     `json:"topic"`
+	Purpose    string     `json:"purpose"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`  // nil for DMs and channels made before it was tracked
+	ArchivedAt *time.Time `json:"archived_at,omitempty"` // set while archived: readable, but no new messages or joins
+}
+
+// IsArchived reports whether the channel has been archived.
+func (c *Channel) IsArchived() bool {
+	return c.ArchivedAt != nil
+}
+
+// IsDM reports whether the channel is a direct or group direct message.
+func (c *Channel) IsDM() bool {
+	return c.Kind == ChannelKindDM || c.Kind == ChannelKindGroupDM
 }
 
 // ChannelMember is the join table between channels and users.
@@ -39,12 +65,137 @@ type ChannelMember struct {
 	Role      string    `json:"role"`
 }
 
+// ChannelUnread is a user's read state in one channel.
+type ChannelUnread struct {
+	ChannelID    uuid.UUID `json:"channel_id"`
+	LastReadID   int64     `json:"last_read_id"`
+	UnreadCount  int       `json:"unread_count"`
+	MentionCount int       `json:"mention_count"`
+}
+
 // Message is a single chat message in a channel.
 // Uses int64 ID (bigserial) instead of UUID for efficient ordering and pagination.
 type Message struct {
-	ID        int64     `json:"id"`
-	ChannelID uuid.UUID `json:"channel_id"`
-	SenderID  uuid.UUID `json:"sender_id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64      `json:"id"`
+	ChannelID uuid.UUID  `json:"channel_id"`
+	SenderID  uuid.UUID  `json:"sender_id"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`  // nil until the first edit
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // set when tombstoned; Body is blanked
+	DeletedBy *uuid.UUID `json:"deleted_by,omitempty"`
+
+	// ClientMsgID is the sender's idempotency key, echoed back so clients
+	// can match the stored message to their optimistic copy.
+	ClientMsgID *string `json:"client_msg_id,omitempty"`
+
+	// Threads: replies carry ParentID; parents carry the counters.
+	ParentID    *int64     `json:"parent_id,omitempty"`
+	ReplyCount  int        `json:"reply_count"`
+	LastReplyAt *time.Time `json:"last_reply_at,omitempty"`
+
+	// Reactions is filled in on reads for the requesting user; it is not a column.
+	Reactions []ReactionSummary `json:"reactions,omitempty"`
+
+	// Attachments is filled in from message_attachments; it is not a column.
+	Attachments []File `json:"attachments,omitempty"`
+}
+
+// File is an uploaded blob's metadata. Files belong to the channel they
+// were uploaded to; only its members can download them.
+type File struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"-"`
+	ChannelID   uuid.UUID `json:"channel_id"`
+	UploaderID  uuid.UUID `json:"uploader_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	StorageKey  string    `json:"-"` // blob store key; never exposed
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Image metadata, set once background processing finishes. Thumbnails
+	// lists the longest-edge sizes available at /v1/files/:id/thumbnails/:size.
+	Width      *int    `json:"width,omitempty"`
+	Height     *int    `json:"height,omitempty"`
+	Blurhash   *string `json:"blurhash,omitempty"`
+	Thumbnails []int   `json:"thumbnails,omitempty"`
+}
+
+// PinnedMessage is a message in a channel's pin list.
+type PinnedMessage struct {
+	Message
+	PinnedBy uuid.UUID `json:"pinned_by"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// ReactionSummary aggregates one emoji's reactions on a message.
+type ReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"` // true if the requesting user is one of Count
+}
+
+// Mention kinds, in order of precedence when a user is mentioned several ways.
+const (
+	MentionUser    = "user"    // <@userID>
+	MentionHere    = "here"    // @here: members online when the message was sent
+	MentionChannel = "channel" // @channel: every member
+)
+
+// Mention is one user notified by a message.
+type Mention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Kind   string    `json:"kind"`
+}
+
+// Scheduled message statuses.
+const (
+	ScheduledPending = "pending"
+	ScheduledSent    = "sent"
+	ScheduledFailed  = "failed"
+)
+
+// ScheduledMessage is a message queued to be sent at SendAt. A failed
+// delivery (e.g. the sender left the channel) keeps the reason in Error.
+type ScheduledMessage struct {
+	ID            uuid.UUID   `json:"id"`
+	TenantID      uuid.UUID   `json:"-"`
+	ChannelID     uuid.UUID   `json:"channel_id"`
+	SenderID      uuid.UUID   `json:"sender_id"`
+	Body          string      `json:"body"`
+	ParentID      *int64      `json:"parent_id,omitempty"`
+	AttachmentIDs []uuid.UUID `json:"attachment_ids,omitempty"`
+	ClientMsgID   *string     `json:"client_msg_id,omitempty"` // the sender's idempotency key, if any
+	SendAt        time.Time   `json:"send_at"`
+	Status        string      `json:"status"`
+	Error         *string     `json:"error,omitempty"`
+	MessageID     *int64      `json:"message_id,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// OutboxEvent is a real-time event stored in the outbox, waiting to be
+// published to Topic. Never sent to clients as-is; Payload is.
+type OutboxEvent struct {
+	ID       int64
+	Topic    string
+	Payload  []byte
+	Attempts int // failed publishes so far
+}
+
+// MentionResult is a message that mentioned the requesting user.
+// The embedded Message fields are flattened into the JSON object.
+type MentionResult struct {
+	Message
+	ChannelName string `json:"channel_name"`
+	Kind        string `json:"mention_kind"`
+}
+
+// SearchResult is a message matched by full-text search.
+// The embedded Message fields are flattened into the JSON object.
+type SearchResult struct {
+	Message
+	ChannelName string `json:"channel_name"`
+	Snippet     string `json:"snippet"` // HTML-escaped body excerpt, matched terms wrapped in <mark></mark>
 }
@@ -12,6 +12,8 @@ import (
 type Client struct {
 	rdb    *goredis.Client
 	logger *zap.Logger
+
+	streamMaxLen int64 // > 0 = Publish appends to the event stream (see UseStreams)
 }
 
 // NewClient connects to Redis and verifies the connection.
@@ -38,8 +40,24 @@ func (c *Client) Close() {
 	}
 }
 
-// Publish sends a message to a Redis pub/sub channel.
+// UseStreams switches Publish from pub/sub to the Redis Streams event
+// stream, keeping about maxLen entries. Call it before anything publishes,
+// and pair it with a Streams listener instead of PubSub.
+func (c *Client) UseStreams(maxLen int64) {
+	c.streamMaxLen = maxLen
+}
+
+// Publish sends a message to a Redis pub/sub channel, or in streams mode
+// appends it to the event stream tagged with that channel as its key.
 func (c *Client) Publish(ctx context.Context, channel string, payload []byte) error {
+	if c.streamMaxLen > 0 {
+		return c.rdb.XAdd(ctx, &goredis.XAddArgs{
+			Stream: eventStream,
+			MaxLen: c.streamMaxLen,
+			Approx: true, // trim lazily, whole macro-nodes at a time
+			Values: map[string]any{streamKeyField: channel, streamPayloadField: payload},
+		}).Err()
+	}
 	return c.rdb.Publish(ctx, channel, payload).Err()
 }
 
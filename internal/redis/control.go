@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// controlKey is the pub/sub key every node listens on for hub control
+// messages. Unlike channel and user keys, its payloads never reach clients.
+const controlKey = "ctl:subscriptions"
+
+// subscriptionControl asks every hub to unsubscribe users from a channel:
+// the listed users, or with Retain set, everyone except them.
+type subscriptionControl struct {
+	ChannelID uuid.UUID   `json:"channel_id"`
+	UserIDs   []uuid.UUID `json:"user_ids"`
+	Retain    bool        `json:"retain,omitempty"`
+}
+
+// EvictSubscribers unsubscribes the given users' websocket clients from
+// the channel on every node, e.g. after they left or were removed.
+func (c *Client) EvictSubscribers(ctx context.Context, channelID uuid.UUID, userIDs []uuid.UUID) error {
+	return c.publishControl(ctx, subscriptionControl{ChannelID: channelID, UserIDs: userIDs})
+}
+
+// RetainSubscribers unsubscribes every websocket client of the channel
+// whose user is not in userIDs on every node, e.g. after it went private.
+func (c *Client) RetainSubscribers(ctx context.Context, channelID uuid.UUID, userIDs []uuid.UUID) error {
+	return c.publishControl(ctx, subscriptionControl{ChannelID: channelID, UserIDs: userIDs, Retain: true})
+}
+
+func (c *Client) publishControl(ctx context.Context, msg subscriptionControl) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal subscription control: %w", err)
+	}
+	if err := c.Publish(ctx, controlKey, payload); err != nil {
+		return fmt.Errorf("publish subscription control: %w", err)
+	}
+	return nil
+}
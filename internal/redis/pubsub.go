@@ -2,15 +2,32 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 
 	"github.com/google/uuid"
 	goredis "github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
-// Broadcaster can send data to all local clients in a channel.
+// Topic prefixes for the Redis pub/sub keys this bridge understands.
+const (
+	channelKeyPrefix = "ch:"
+	userKeyPrefix    = "user:"
+	tenantKeyPrefix  = "tenant:"
+)
+
+// Broadcaster can send data to all local clients in a channel, to every
+// local connection of one user or tenant, drop local channel
+// subscriptions when told to over the control key, and tell every local
+// client to resync after events were lost.
 type Broadcaster interface {
 	Broadcast(channelID uuid.UUID, data []byte)
+	BroadcastToUser(userID uuid.UUID, data []byte)
+	BroadcastToTenant(tenantID uuid.UUID, data []byte)
+	EvictSubscribers(channelID uuid.UUID, userIDs []uuid.UUID)
+	RetainSubscribers(channelID uuid.UUID, userIDs []uuid.UUID)
+	Resync()
 }
 
 // PubSub bridges Redis pub/sub with the local WebSocket hub.
@@ -24,8 +41,9 @@ type PubSub struct {
 // NewPubSub creates a Redis pub/sub bridge for the local hub.
 func NewPubSub(client *Client, hub Broadcaster, logger *zap.Logger) *PubSub {
 	return &PubSub{
-		// Start with no channels; callers subscribe as channels become active.
-		sub:    client.rdb.Subscribe(context.Background()),
+		// Start with just the control key; callers subscribe as channels
+		// and users become active.
+		sub:    client.rdb.Subscribe(context.Background(), controlKey),
 		client: client,
 		hub:    hub,
 		logger: logger,
@@ -34,20 +52,56 @@ func NewPubSub(client *Client, hub Broadcaster, logger *zap.Logger) *PubSub {
 
 // ChannelKey returns the Redis pub/sub key for a channel UUID.
 func ChannelKey(channelID uuid.UUID) string {
-	return "ch:" + channelID.String()
+	return channelKeyPrefix + channelID.String()
+}
+
+// UserKey returns the Redis pub/sub key for a user's personal stream.
+func UserKey(userID uuid.UUID) string {
+	return userKeyPrefix + userID.String()
+}
+
+// TenantKey returns the Redis pub/sub key for tenant-wide events.
+func TenantKey(tenantID uuid.UUID) string {
+	return tenantKeyPrefix + tenantID.String()
 }
 
 // Subscribe tells Redis to start delivering messages for this channel.
 func (ps *PubSub) Subscribe(channelID uuid.UUID) {
-	key := ChannelKey(channelID)
+	ps.subscribe(ChannelKey(channelID))
+}
+
+// Unsubscribe tells Redis to stop delivering messages for this channel.
+func (ps *PubSub) Unsubscribe(channelID uuid.UUID) {
+	ps.unsubscribe(ChannelKey(channelID))
+}
+
+// SubscribeUser starts delivering a user's personal events to this node.
+func (ps *PubSub) SubscribeUser(userID uuid.UUID) {
+	ps.subscribe(UserKey(userID))
+}
+
+// UnsubscribeUser stops delivering a user's personal events to this node.
+func (ps *PubSub) UnsubscribeUser(userID uuid.UUID) {
+	ps.unsubscribe(UserKey(userID))
+}
+
+// SubscribeTenant starts delivering a tenant's events to this node.
+func (ps *PubSub) SubscribeTenant(tenantID uuid.UUID) {
+	ps.subscribe(TenantKey(tenantID))
+}
+
+// UnsubscribeTenant stops delivering a tenant's events to this node.
+func (ps *PubSub) UnsubscribeTenant(tenantID uuid.UUID) {
+	ps.unsubscribe(TenantKey(tenantID))
+}
+
+func (ps *PubSub) subscribe(key string) {
 	if err := ps.sub.Subscribe(context.Background(), key); err != nil {
 		ps.logger.Error("redis subscribe failed", zap.String("channel", key), zap.Error(err))
 	}
 }
 
-// Unsubscribe tells Redis to stop delivering messages for this channel.
-func (ps *PubSub) Unsubscribe(channelID uuid.UUID) {
-	key := ChannelKey(channelID)
+func (ps *PubSub) unsubscribe(key string) {
 	if err := ps.sub.Unsubscribe(context.Background(), key); err != nil {
 		ps.logger.Error("redis unsubscribe failed", zap.String("channel", key), zap.Error(err))
 	}
@@ -64,20 +118,56 @@ func (ps *PubSub) Listen(ctx context.Context) {
 			if !ok {
 				return
 			}
-			// Channel keys are stored as "ch:<uuid>".
-			if len(msg.Channel) <= 3 {
-				continue
-			}
-			channelID, err := uuid.Parse(msg.Channel[3:])
-			if err != nil {
-				ps.logger.Warn("invalid channel key from redis", zap.String("key", msg.Channel))
-				continue
-			}
-			ps.hub.Broadcast(channelID, []byte(msg.Payload))
+			dispatch(ps.hub, ps.logger, msg.Channel, []byte(msg.Payload))
 		}
 	}
 }
 
+// dispatch routes a payload by key: "ch:<uuid>" fans out to channel
+// subscribers, "user:<uuid>" to every connection of that user,
+// "tenant:<uuid>" to every connection in the tenant, and the control key
+// is applied to the hub itself. Shared by PubSub and Streams.
+func dispatch(hub Broadcaster, logger *zap.Logger, key string, payload []byte) {
+	if key == controlKey {
+		applyControl(hub, logger, payload)
+		return
+	}
+
+	var route func(uuid.UUID, []byte)
+	var rest string
+	switch {
+	case strings.HasPrefix(key, channelKeyPrefix):
+		route, rest = hub.Broadcast, key[len(channelKeyPrefix):]
+	case strings.HasPrefix(key, userKeyPrefix):
+		route, rest = hub.BroadcastToUser, key[len(userKeyPrefix):]
+	case strings.HasPrefix(key, tenantKeyPrefix):
+		route, rest = hub.BroadcastToTenant, key[len(tenantKeyPrefix):]
+	default:
+		return
+	}
+
+	id, err := uuid.Parse(rest)
+	if err != nil {
+		logger.Warn("invalid key from redis", zap.String("key", key))
+		return
+	}
+	route(id, payload)
+}
+
+// applyControl applies a subscriptionControl message to the local hub.
+func applyControl(hub Broadcaster, logger *zap.Logger, payload []byte) {
+	var msg subscriptionControl
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		logger.Warn("invalid control message from redis", zap.Error(err))
+		return
+	}
+	if msg.Retain {
+		hub.RetainSubscribers(msg.ChannelID, msg.UserIDs)
+	} else {
+		hub.EvictSubscribers(msg.ChannelID, msg.UserIDs)
+	}
+}
+
 // Close releases the Redis pub/sub subscription.
 func (ps *PubSub) Close() {
 	if err := ps.sub.Close(); err != nil {
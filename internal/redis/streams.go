@@ -0,0 +1,289 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// eventStream is the single Redis stream every node appends to and
+	// reads in full. Entries carry the pub/sub key they would have been
+	// published on, so routing is the same as with PubSub.
+	eventStream        = "events"
+	streamKeyField     = "key"
+	streamPayloadField = "payload"
+
+	// streamOffsetPrefix + node ID holds the ID of the last entry the
+	// node delivered. It outlives restarts, and expires for good after
+	// streamOffsetTTL so retired nodes don't leave keys behind.
+	streamOffsetPrefix = "events:offset:"
+	streamOffsetTTL    = 7 * 24 * time.Hour
+
+	streamReadCount = 256
+	streamBlock     = 5 * time.Second
+	streamRetryMin  = 100 * time.Millisecond
+	streamRetryMax  = 5 * time.Second
+)
+
+// streamClient is the part of go-redis that Streams uses.
+type streamClient interface {
+	XRead(ctx context.Context, a *goredis.XReadArgs) *goredis.XStreamSliceCmd
+	XRangeN(ctx context.Context, stream, start, stop string, count int64) *goredis.XMessageSliceCmd
+	XRevRangeN(ctx context.Context, stream, start, stop string, count int64) *goredis.XMessageSliceCmd
+	Get(ctx context.Context, key string) *goredis.StringCmd
+	Set(ctx context.Context, key string, value any, expiration time.Duration) *goredis.StatusCmd
+}
+
+// Streams bridges the Redis event stream with the local WebSocket hub. It
+// is the durable alternative to PubSub: each node reads from its own
+// offset, saved in Redis after every batch, so events appended while the
+// node is disconnected or restarting are delivered once it's back.
+//
+// Only a node with no saved offset (first start, or away longer than
+// streamOffsetTTL) starts at the end of the stream. If the stream was
+// trimmed past the saved offset, the loss is logged and local clients get
+// a "resync" event telling them to catch up over HTTP.
+type Streams struct {
+	rdb       streamClient
+	hub       Broadcaster
+	offsetKey string
+	logger    *zap.Logger
+
+	mu     sync.Mutex
+	active map[string]struct{} // keys with local listeners; others are skipped
+}
+
+// NewStreams creates a Redis Streams bridge for the local hub. The client
+// must be in streams mode (see Client.UseStreams). nodeID must be stable
+// across restarts and unique among the nodes sharing the stream.
+func NewStreams(client *Client, hub Broadcaster, nodeID string, logger *zap.Logger) *Streams {
+	return newStreams(client.rdb, hub, nodeID, logger)
+}
+
+func newStreams(rdb streamClient, hub Broadcaster, nodeID string, logger *zap.Logger) *Streams {
+	return &Streams{
+		rdb:       rdb,
+		hub:       hub,
+		offsetKey: streamOffsetPrefix + nodeID,
+		logger:    logger,
+		active:    map[string]struct{}{controlKey: {}},
+	}
+}
+
+// Subscribe starts delivering a channel's events to the hub.
+func (s *Streams) Subscribe(channelID uuid.UUID) {
+	s.activate(ChannelKey(channelID))
+}
+
+// Unsubscribe stops delivering a channel's events to the hub.
+func (s *Streams) Unsubscribe(channelID uuid.UUID) {
+	s.deactivate(ChannelKey(channelID))
+}
+
+// SubscribeUser starts delivering a user's personal events to the hub.
+func (s *Streams) SubscribeUser(userID uuid.UUID) {
+	s.activate(UserKey(userID))
+}
+
+// UnsubscribeUser stops delivering a user's personal events to the hub.
+func (s *Streams) UnsubscribeUser(userID uuid.UUID) {
+	s.deactivate(UserKey(userID))
+}
+
+// SubscribeTenant starts delivering a tenant's events to the hub.
+func (s *Streams) SubscribeTenant(tenantID uuid.UUID) {
+	s.activate(TenantKey(tenantID))
+}
+
+// UnsubscribeTenant stops delivering a tenant's events to the hub.
+func (s *Streams) UnsubscribeTenant(tenantID uuid.UUID) {
+	s.deactivate(TenantKey(tenantID))
+}
+
+func (s *Streams) activate(key string) {
+	s.mu.Lock()
+	s.active[key] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *Streams) deactivate(key string) {
+	s.mu.Lock()
+	delete(s.active, key)
+	s.mu.Unlock()
+}
+
+func (s *Streams) isActive(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.active[key]
+	return ok
+}
+
+// Listen reads the event stream from this node's saved offset and
+// forwards entries for active keys to the Hub. Read errors are retried
+// with backoff from the last entry seen, so nothing still in the stream is
+// skipped.
+func (s *Streams) Listen(ctx context.Context) {
+	backoff := streamRetryMin
+	retry := func(msg string, err error) bool {
+		s.logger.Error(msg, zap.Error(err), zap.Duration("retry_in", backoff))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, streamRetryMax)
+		return true
+	}
+
+	var lastID string
+	for lastID == "" {
+		id, err := s.startID(ctx)
+		if err != nil {
+			if ctx.Err() != nil || !retry("redis stream offset lookup failed", err) {
+				return
+			}
+			continue
+		}
+		lastID = id
+	}
+	// Save even a fresh tail, so a restart before the first event still
+	// resumes from here.
+	s.saveOffset(ctx, lastID)
+
+	resumed := false // after a read error: the stream may have been trimmed meanwhile
+	for {
+		if resumed {
+			if err := s.checkGap(ctx, lastID); err != nil {
+				if ctx.Err() != nil || !retry("redis stream gap check failed", err) {
+					return
+				}
+				continue
+			}
+			resumed = false
+		}
+
+		res, err := s.rdb.XRead(ctx, &goredis.XReadArgs{
+			Streams: []string{eventStream, lastID},
+			Count:   streamReadCount,
+			Block:   streamBlock,
+		}).Result()
+		if errors.Is(err, goredis.Nil) {
+			continue // nothing new within streamBlock
+		}
+		if err != nil {
+			if ctx.Err() != nil || !retry("redis stream read failed", err) {
+				return
+			}
+			resumed = true
+			continue
+		}
+		backoff = streamRetryMin
+
+		for _, stream := range res {
+			for _, msg := range stream.Messages {
+				lastID = msg.ID
+				s.deliver(msg)
+			}
+		}
+		s.saveOffset(ctx, lastID)
+	}
+}
+
+// startID returns where Listen should start reading: after the saved
+// offset if there is one, otherwise after the newest entry.
+func (s *Streams) startID(ctx context.Context) (string, error) {
+	saved, err := s.rdb.Get(ctx, s.offsetKey).Result()
+	if errors.Is(err, goredis.Nil) {
+		s.logger.Info("no saved stream offset, starting at the end of the stream")
+		return s.tail(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkGap(ctx, saved); err != nil {
+		return "", err
+	}
+	return saved, nil
+}
+
+// tail returns the ID of the newest entry in the stream, or "0-0" if it's
+// empty, so reading starts after everything already there.
+func (s *Streams) tail(ctx context.Context) (string, error) {
+	msgs, err := s.rdb.XRevRangeN(ctx, eventStream, "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return "0-0", nil
+	}
+	return msgs[0].ID, nil
+}
+
+// checkGap reports events trimmed from the stream before this node read
+// them: if the oldest entry left is newer than lastID, whatever came
+// between is gone. Local clients are told to resync.
+func (s *Streams) checkGap(ctx context.Context, lastID string) error {
+	msgs, err := s.rdb.XRangeN(ctx, eventStream, "-", "+", 1).Result()
+	if err != nil {
+		return err
+	}
+	if len(msgs) == 0 || !streamIDBefore(lastID, msgs[0].ID) {
+		return nil
+	}
+	s.logger.Error("event stream was trimmed past this node's offset, events were lost",
+		zap.String("last_seen_id", lastID),
+		zap.String("oldest_id", msgs[0].ID),
+	)
+	s.hub.Resync()
+	return nil
+}
+
+// saveOffset records lastID as this node's offset. A failed save is only
+// logged: Listen keeps its position in memory, and at worst a restart
+// redelivers a few events.
+func (s *Streams) saveOffset(ctx context.Context, lastID string) {
+	if err := s.rdb.Set(ctx, s.offsetKey, lastID, streamOffsetTTL).Err(); err != nil && ctx.Err() == nil {
+		s.logger.Warn("failed to save stream offset", zap.Error(err))
+	}
+}
+
+// streamIDBefore reports whether stream entry ID a ("<ms>-<seq>") sorts
+// before b.
+func streamIDBefore(a, b string) bool {
+	aMS, aSeq := splitStreamID(a)
+	bMS, bSeq := splitStreamID(b)
+	return aMS < bMS || (aMS == bMS && aSeq < bSeq)
+}
+
+func splitStreamID(id string) (ms, seq uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ = strconv.ParseUint(msPart, 10, 64)
+	seq, _ = strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
+
+func (s *Streams) deliver(msg goredis.XMessage) {
+	key, _ := msg.Values[streamKeyField].(string)
+	payload, _ := msg.Values[streamPayloadField].(string)
+	if key == "" {
+		s.logger.Warn("stream entry without a key", zap.String("id", msg.ID))
+		return
+	}
+	if !s.isActive(key) {
+		return
+	}
+	dispatch(s.hub, s.logger, key, []byte(payload))
+}
+
+// Close is a no-op; Listen stops when its context is cancelled. It exists
+// so Streams can stand in for PubSub.
+func (s *Streams) Close() {}
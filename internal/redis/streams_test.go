@@ -0,0 +1,296 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// fakeHub records what the bridges hand to the local hub.
+type fakeHub struct {
+	mu        sync.Mutex
+	channels  []uuid.UUID
+	users     []uuid.UUID
+	tenants   []uuid.UUID
+	evicted   []uuid.UUID
+	retained  []uuid.UUID
+	resyncs   int
+	delivered chan struct{}
+}
+
+func newFakeHub() *fakeHub {
+	return &fakeHub{delivered: make(chan struct{}, 64)}
+}
+
+func (h *fakeHub) Broadcast(channelID uuid.UUID, _ []byte) {
+	h.mu.Lock()
+	h.channels = append(h.channels, channelID)
+	h.mu.Unlock()
+	h.delivered <- struct{}{}
+}
+
+func (h *fakeHub) BroadcastToUser(userID uuid.UUID, _ []byte) {
+	h.mu.Lock()
+	h.users = append(h.users, userID)
+	h.mu.Unlock()
+}
+
+func (h *fakeHub) BroadcastToTenant(tenantID uuid.UUID, _ []byte) {
+	h.mu.Lock()
+	h.tenants = append(h.tenants, tenantID)
+	h.mu.Unlock()
+}
+
+func (h *fakeHub) EvictSubscribers(_ uuid.UUID, userIDs []uuid.UUID) {
+	h.mu.Lock()
+	h.evicted = append(h.evicted, userIDs...)
+	h.mu.Unlock()
+}
+
+func (h *fakeHub) RetainSubscribers(_ uuid.UUID, userIDs []uuid.UUID) {
+	h.mu.Lock()
+	h.retained = append(h.retained, userIDs...)
+	h.mu.Unlock()
+}
+
+func (h *fakeHub) Resync() {
+	h.mu.Lock()
+	h.resyncs++
+	h.mu.Unlock()
+}
+
+// xreadResult is one scripted XRead reply.
+type xreadResult struct {
+	msgs []goredis.XMessage
+	err  error
+}
+
+// fakeStreamClient scripts the stream commands Streams issues. XRead
+// replies are consumed in order; once they run out it blocks until the
+// context is cancelled.
+type fakeStreamClient struct {
+	mu      sync.Mutex
+	offset  string // "" = no saved offset
+	oldest  string // first entry in the stream, "" = empty
+	newest  string
+	reads   []xreadResult
+	readIDs []string // the ID each XRead started after
+	saved   []string
+}
+
+func (f *fakeStreamClient) XRead(ctx context.Context, a *goredis.XReadArgs) *goredis.XStreamSliceCmd {
+	f.mu.Lock()
+	f.readIDs = append(f.readIDs, a.Streams[1])
+	if len(f.reads) == 0 {
+		f.mu.Unlock()
+		<-ctx.Done()
+		return goredis.NewXStreamSliceCmdResult(nil, ctx.Err())
+	}
+	r := f.reads[0]
+	f.reads = f.reads[1:]
+	f.mu.Unlock()
+	if r.err != nil {
+		return goredis.NewXStreamSliceCmdResult(nil, r.err)
+	}
+	return goredis.NewXStreamSliceCmdResult([]goredis.XStream{{Stream: eventStream, Messages: r.msgs}}, nil)
+}
+
+func (f *fakeStreamClient) XRangeN(_ context.Context, _, _, _ string, _ int64) *goredis.XMessageSliceCmd {
+	return f.entry(f.oldest)
+}
+
+func (f *fakeStreamClient) XRevRangeN(_ context.Context, _, _, _ string, _ int64) *goredis.XMessageSliceCmd {
+	return f.entry(f.newest)
+}
+
+func (f *fakeStreamClient) entry(id string) *goredis.XMessageSliceCmd {
+	if id == "" {
+		return goredis.NewXMessageSliceCmdResult(nil, nil)
+	}
+	return goredis.NewXMessageSliceCmdResult([]goredis.XMessage{{ID: id}}, nil)
+}
+
+func (f *fakeStreamClient) Get(_ context.Context, _ string) *goredis.StringCmd {
+	if f.offset == "" {
+		return goredis.NewStringResult("", goredis.Nil)
+	}
+	return goredis.NewStringResult(f.offset, nil)
+}
+
+func (f *fakeStreamClient) Set(_ context.Context, _ string, value any, _ time.Duration) *goredis.StatusCmd {
+	f.mu.Lock()
+	f.saved = append(f.saved, value.(string))
+	f.mu.Unlock()
+	return goredis.NewStatusResult("OK", nil)
+}
+
+func streamEntry(id, key string) goredis.XMessage {
+	return goredis.XMessage{ID: id, Values: map[string]any{streamKeyField: key, streamPayloadField: "{}"}}
+}
+
+// listenUntil runs Listen until the hub has seen n channel broadcasts.
+func listenUntil(t *testing.T, s *Streams, hub *fakeHub, n int) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Listen(ctx)
+	}()
+	for i := 0; i < n; i++ {
+		select {
+		case <-hub.delivered:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %d of %d deliveries", i, n)
+		}
+	}
+	cancel()
+	<-done
+}
+
+func TestDeliver_OnlyActiveKeys(t *testing.T) {
+	hub := newFakeHub()
+	s := newStreams(&fakeStreamClient{}, hub, "node-1", zap.NewNop())
+	chID := uuid.New()
+
+	s.deliver(streamEntry("1-0", ChannelKey(chID)))
+	if len(hub.channels) != 0 {
+		t.Fatalf("inactive channel should be skipped, got %v", hub.channels)
+	}
+
+	s.Subscribe(chID)
+	s.deliver(streamEntry("2-0", ChannelKey(chID)))
+	<-hub.delivered
+	if len(hub.channels) != 1 || hub.channels[0] != chID {
+		t.Fatalf("expected one broadcast to %s, got %v", chID, hub.channels)
+	}
+
+	s.Unsubscribe(chID)
+	s.deliver(streamEntry("3-0", ChannelKey(chID)))
+	if len(hub.channels) != 1 {
+		t.Fatalf("unsubscribed channel should be skipped, got %v", hub.channels)
+	}
+}
+
+func TestDeliver_ControlKeyAlwaysActive(t *testing.T) {
+	hub := newFakeHub()
+	s := newStreams(&fakeStreamClient{}, hub, "node-1", zap.NewNop())
+	userID := uuid.New()
+	payload, _ := json.Marshal(subscriptionControl{ChannelID: uuid.New(), UserIDs: []uuid.UUID{userID}})
+
+	s.deliver(goredis.XMessage{ID: "1-0", Values: map[string]any{streamKeyField: controlKey, streamPayloadField: string(payload)}})
+	if len(hub.evicted) != 1 || hub.evicted[0] != userID {
+		t.Fatalf("expected %s evicted, got %v", userID, hub.evicted)
+	}
+}
+
+func TestApplyControl(t *testing.T) {
+	hub := newFakeHub()
+	kept, gone := uuid.New(), uuid.New()
+
+	retain, _ := json.Marshal(subscriptionControl{ChannelID: uuid.New(), UserIDs: []uuid.UUID{kept}, Retain: true})
+	applyControl(hub, zap.NewNop(), retain)
+	evict, _ := json.Marshal(subscriptionControl{ChannelID: uuid.New(), UserIDs: []uuid.UUID{gone}})
+	applyControl(hub, zap.NewNop(), evict)
+	applyControl(hub, zap.NewNop(), []byte("not json"))
+
+	if len(hub.retained) != 1 || hub.retained[0] != kept {
+		t.Fatalf("expected %s retained, got %v", kept, hub.retained)
+	}
+	if len(hub.evicted) != 1 || hub.evicted[0] != gone {
+		t.Fatalf("expected %s evicted, got %v", gone, hub.evicted)
+	}
+}
+
+func TestListen_ResumesFromSavedOffsetAfterErrors(t *testing.T) {
+	hub := newFakeHub()
+	chID := uuid.New()
+	rdb := &fakeStreamClient{
+		offset: "5-0",
+		oldest: "1-0",
+		reads: []xreadResult{
+			{msgs: []goredis.XMessage{streamEntry("6-0", ChannelKey(chID))}},
+			{err: errors.New("connection reset")},
+			{msgs: []goredis.XMessage{streamEntry("7-0", ChannelKey(chID))}},
+		},
+	}
+	s := newStreams(rdb, hub, "node-1", zap.NewNop())
+	s.Subscribe(chID)
+
+	listenUntil(t, s, hub, 2)
+
+	rdb.mu.Lock()
+	defer rdb.mu.Unlock()
+	if len(rdb.readIDs) < 3 || rdb.readIDs[0] != "5-0" || rdb.readIDs[1] != "6-0" || rdb.readIDs[2] != "6-0" {
+		t.Fatalf("expected reads after 5-0, then 6-0 twice, got %v", rdb.readIDs)
+	}
+	if last := rdb.saved[len(rdb.saved)-1]; last != "7-0" {
+		t.Fatalf("expected offset 7-0 saved, got %v", rdb.saved)
+	}
+	if hub.resyncs != 0 {
+		t.Fatalf("no gap expected, got %d resyncs", hub.resyncs)
+	}
+}
+
+func TestListen_StartsAtTailWithoutOffset(t *testing.T) {
+	hub := newFakeHub()
+	chID := uuid.New()
+	rdb := &fakeStreamClient{
+		newest: "9-0",
+		reads:  []xreadResult{{msgs: []goredis.XMessage{streamEntry("10-0", ChannelKey(chID))}}},
+	}
+	s := newStreams(rdb, hub, "node-1", zap.NewNop())
+	s.Subscribe(chID)
+
+	listenUntil(t, s, hub, 1)
+
+	rdb.mu.Lock()
+	defer rdb.mu.Unlock()
+	if rdb.readIDs[0] != "9-0" || rdb.saved[0] != "9-0" {
+		t.Fatalf("expected to start and save at the tail 9-0, got reads %v saved %v", rdb.readIDs, rdb.saved)
+	}
+}
+
+func TestListen_ReportsTrimmedGap(t *testing.T) {
+	hub := newFakeHub()
+	chID := uuid.New()
+	rdb := &fakeStreamClient{
+		offset: "5-0",
+		oldest: "8-0", // 6 and 7 were trimmed before this node read them
+		reads:  []xreadResult{{msgs: []goredis.XMessage{streamEntry("8-0", ChannelKey(chID))}}},
+	}
+	s := newStreams(rdb, hub, "node-1", zap.NewNop())
+	s.Subscribe(chID)
+
+	listenUntil(t, s, hub, 1)
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.resyncs != 1 {
+		t.Fatalf("expected one resync for the trimmed gap, got %d", hub.resyncs)
+	}
+}
+
+func TestStreamIDBefore(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"5-0", "6-0", true},
+		{"5-1", "5-2", true},
+		{"10-0", "9-5", false},
+		{"7-3", "7-3", false},
+	}
+	for _, c := range cases {
+		if got := streamIDBefore(c.a, c.b); got != c.want {
+			t.Errorf("streamIDBefore(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -2,46 +2,286 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lalith-99/echostream/internal/models"
 )
 
+// ErrChannelNameTaken is returned by ChannelRepository.Create and Update
+// when another channel in the tenant already has the name.
+var ErrChannelNameTaken = errors.New("channel name already taken")
+
 // ChannelRepository defines the contract for channel data operations.
 // All queries are scoped to a tenant for multi-tenancy isolation.
 type ChannelRepository interface {
 	// Create inserts a new channel and returns it with ID and CreatedAt populated.
-	Create(ctx context.Context, tenantID uuid.UUID, name string, isPrivate bool) (*models.Channel, error)
+	// Returns ErrChannelNameTaken if the name is in use in the tenant.
+	Create(ctx context.Context, p CreateChannelParams) (*models.Channel, error)
 
 	// GetByID returns a single channel. Returns nil, nil if not found.
 	GetByID(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID) (*models.Channel, error)
 
-	// ListByTenant returns channels for a tenant, newest first, with pagination.
-	ListByTenant(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]models.Channel, error)
+	// GetByName returns the regular channel (not a DM) with this exact
+	// name. Returns nil, nil if not found.
+	GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.Channel, error)
+
+	// ListByTenant returns the regular channels (DMs excluded) viewerID can
+	// see: every public channel plus the private ones they belong to,
+	// newest first, with pagination.
+	ListByTenant(ctx context.Context, tenantID, viewerID uuid.UUID, limit, offset int) ([]models.Channel, error)
+
+	// FindOrCreateDM returns the DM channel for exactly this participant set,
+	// creating it (with every participant as a member) if it doesn't exist.
+	// memberIDs must be de-duplicated and sorted. created reports whether a
+	// new channel was made.
+	FindOrCreateDM(ctx context.Context, tenantID uuid.UUID, memberIDs []uuid.UUID) (ch *models.Channel, created bool, err error)
+
+	// Update applies the non-nil fields of u and returns the updated
+	// channel. Returns nil, nil if not found, ErrChannelNameTaken on a
+	// rename to a name already in use.
+	Update(ctx context.Context, tenantID, channelID uuid.UUID, u ChannelUpdate) (*models.Channel, error)
+
+	// SetPrivate switches is_private and writes a channel_audit_log entry
+	// for actorID in the same transaction. changed is false (and nothing is
+	// written) if the channel already had that setting. Returns nil, false,
+	// nil if not found.
+	SetPrivate(ctx context.Context, tenantID, channelID, actorID uuid.UUID, private bool) (ch *models.Channel, changed bool, err error)
+}
+
+// CreateChannelParams is the input to ChannelRepository.Create.
+type CreateChannelParams struct {
+	TenantID      uuid.UUID
+	Name          string
+	IsPrivate     bool
+	PinsAdminOnly bool
+	CreatedBy     uuid.UUID
+}
+
+// ChannelUpdate is the input to ChannelRepository.Update. Nil fields are
+// left unchanged.
+type ChannelUpdate struct {
+	Name     *string
+	Topic    *string
+	Purpose  *string
+	Archived *bool // true sets archived_at (keeping an earlier one), false clears it
 }
 
 // MembershipRepository handles who belongs to which channel.
 type MembershipRepository interface {
-	// AddMember adds a user to a channel with the given role.
-	AddMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID, role string) error
+	// AddMember adds a user to a channel with the given role. Returns
+	// false, changing nothing, if they're already a member.
+	AddMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID, role string) (bool, error)
 
-	// RemoveMember removes a user from a channel. No-op if not a member.
-	RemoveMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID) error
+	// RemoveMember removes a user from a channel. Returns false if they
+	// weren't a member.
+	RemoveMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID) (bool, error)
 
 	// ListMembers returns members of a channel with pagination.
 	ListMembers(ctx context.Context, channelID uuid.UUID, limit, offset int) ([]models.ChannelMember, error)
 
 	// IsMember checks if a user belongs to a channel.
 	IsMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID) (bool, error)
+
+	// GetRole returns the user's role in a channel. Returns "" if not a member.
+	GetRole(ctx context.Context, channelID uuid.UUID, userID uuid.UUID) (string, error)
+
+	// UpdateRole sets a member's role and audits it as done by actorID.
+	// Returns false if userID is not a member.
+	UpdateRole(ctx context.Context, tenantID, channelID, actorID, userID uuid.UUID, role string) (bool, error)
+
+	// TransferOwnership makes toID the owner and fromID an admin, audited,
+	// in one transaction. Returns false (changing nothing) unless fromID
+	// is the current owner and toID a member.
+	TransferOwnership(ctx context.Context, tenantID, channelID, fromID, toID uuid.UUID) (bool, error)
+
+	// Kick removes userID from the channel and audits it as done by
+	// actorID. Returns false if userID was not a member.
+	Kick(ctx context.Context, tenantID, channelID, actorID, userID uuid.UUID) (bool, error)
+
+	// MarkRead moves the user's read cursor forward to messageID.
+	// Returns false if the cursor was already at or past it (or not a member).
+	MarkRead(ctx context.Context, channelID uuid.UUID, userID uuid.UUID, messageID int64) (bool, error)
+
+	// ListMemberIDs returns every member's user ID (for @channel / @here).
+	ListMemberIDs(ctx context.Context, channelID uuid.UUID) ([]uuid.UUID, error)
+
+	// Unreads returns read state for every channel the user belongs to.
+	Unreads(ctx context.Context, tenantID uuid.UUID, userID uuid.UUID) ([]models.ChannelUnread, error)
+}
+
+// CreateMessageParams holds the fields for a new message.
+type CreateMessageParams struct {
+	TenantID  uuid.UUID
+	ChannelID uuid.UUID
+	SenderID  uuid.UUID
+	Body      string
+	ParentID  int64 // 0 = top-level message, otherwise the thread parent's ID
+
+	// ClientMsgID is an optional idempotency key, unique per (sender, channel).
+	ClientMsgID string
+
+	// Mentions are stored with the message in the same transaction.
+	Mentions []models.Mention
+
+	// AttachmentIDs are linked to the message in the given order.
+	AttachmentIDs []uuid.UUID
+
+	// Outbox, if set, builds the events announcing the new message. They
+	// are written to the outbox in the same transaction, so a committed
+	// message is always published eventually. Not called for a retry.
+	Outbox func(msg *models.Message) ([]models.OutboxEvent, error)
+}
+
+// MessageSearch is a parsed search query. Zero-valued fields don't filter.
+type MessageSearch struct {
+	Text        string    // websearch_to_tsquery syntax: words and "quoted phrases"
+	ChannelID   uuid.UUID // in:<uuid>
+	ChannelName string    // in:<name>
+	SenderID    uuid.UUID // from:<uuid> or from:me
+	Sender      string    // from:<email or display name>, case-insensitive
+	Before      time.Time // created strictly before
+	After       time.Time // created at or after
 }
 
 // MessageRepository handles chat message persistence.
 type MessageRepository interface {
 	// Create persists a message and returns it with ID and CreatedAt populated.
-	Create(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, senderID uuid.UUID, body string) (*models.Message, error)
+	// For thread replies it also bumps the parent's reply_count/last_reply_at
+	// in the same transaction.
+	//
+	// If p.ClientMsgID was already used by this sender in this channel, no
+	// row is written: the original message is returned with created=false.
+	Create(ctx context.Context, p CreateMessageParams) (msg *models.Message, created bool, err error)
 
-	// ListByChannel returns messages in a channel, newest first, with cursor pagination.
+	// ListByChannel returns top-level messages in a channel (thread replies
+	// excluded), newest first, with cursor pagination.
 	ListByChannel(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, before int64, limit int) ([]models.Message, error)
+
+	// ListAfter returns top-level messages with id > after, oldest first.
+	// Used by reconnecting clients to catch up on what they missed.
+	ListAfter(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, after int64, limit int) ([]models.Message, error)
+
+	// ListReplies returns the replies to a thread parent, newest first, with
+	// the same before-cursor pagination as ListByChannel.
+	ListReplies(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, parentID int64, before int64, limit int) ([]models.Message, error)
+
+	// Search returns live messages matching q, newest first, with the same
+	// before-cursor pagination as ListByChannel. Results are scoped to the
+	// tenant and to channels viewerID is a member of.
+	Search(ctx context.Context, tenantID uuid.UUID, viewerID uuid.UUID, q MessageSearch, before int64, limit int) ([]models.SearchResult, error)
+
+	// ListMentions returns live messages that mentioned userID, newest first,
+	// across channels the user still belongs to.
+	ListMentions(ctx context.Context, tenantID uuid.UUID, userID uuid.UUID, before int64, limit int) ([]models.MentionResult, error)
+
+	// GetByID returns a single message in a channel. Returns nil, nil if not found.
+	GetByID(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, messageID int64) (*models.Message, error)
+
+	// Edit replaces a message body and its mentions and records the previous
+	// body in the edit history, atomically. added holds the mentions of
+	// users the message didn't mention before. Returns nil if the message
+	// does not exist.
+	Edit(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, messageID int64, editorID uuid.UUID, body string, mentions []models.Mention) (msg *models.Message, added []models.Mention, err error)
+
+	// SoftDelete tombstones a message: sets deleted_at/deleted_by, blanks the
+	// body and drops its edit history, pin and attachments. Files no other
+	// message uses are deleted too. Deleting a reply also takes it off its
+	// parent's reply_count and last_reply_at. unpinned reports whether a
+	// pin was removed. Returns nil if the message does not exist or is
+	// already deleted.
+	SoftDelete(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, messageID int64, deletedBy uuid.UUID) (msg *models.Message, unpinned bool, err error)
+}
+
+// PinRepository handles pinned messages. Like ReactionRepository, callers
+// check the message belongs to the channel and tenant first.
+type PinRepository interface {
+	// Pin pins a message. Returns false if it was already pinned.
+	Pin(ctx context.Context, channelID uuid.UUID, messageID int64, pinnedBy uuid.UUID) (bool, error)
+
+	// Unpin removes a pin. Returns false if the message wasn't pinned.
+	Unpin(ctx context.Context, channelID uuid.UUID, messageID int64) (bool, error)
+
+	// List returns a channel's pinned live messages, most recently pinned first.
+	List(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID) ([]models.PinnedMessage, error)
+}
+
+// ScheduledMessageRepository stores messages queued for later delivery.
+// Reads and edits are scoped to the sender: a scheduled message is
+// private to its author until it is sent.
+type ScheduledMessageRepository interface {
+	// Create queues a message and returns it with ID and timestamps
+	// populated. If m.ClientMsgID was already used by this sender in this
+	// channel, it returns the existing row with created = false.
+	Create(ctx context.Context, m *models.ScheduledMessage) (msg *models.ScheduledMessage, created bool, err error)
+
+	// GetByID returns one of the sender's scheduled messages. Returns nil,
+	// nil if not found.
+	GetByID(ctx context.Context, tenantID uuid.UUID, senderID uuid.UUID, id uuid.UUID) (*models.ScheduledMessage, error)
+
+	// ListBySender returns the sender's pending and failed scheduled
+	// messages, soonest first.
+	ListBySender(ctx context.Context, tenantID uuid.UUID, senderID uuid.UUID) ([]models.ScheduledMessage, error)
+
+	// Update changes the body and send time of a pending message that is
+	// not currently being delivered. Returns nil, nil otherwise.
+	Update(ctx context.Context, tenantID uuid.UUID, senderID uuid.UUID, id uuid.UUID, body string, sendAt time.Time) (*models.ScheduledMessage, error)
+
+	// Delete cancels a pending or failed message that is not currently
+	// being delivered. Returns false if there was nothing to cancel.
+	Delete(ctx context.Context, tenantID uuid.UUID, senderID uuid.UUID, id uuid.UUID) (bool, error)
+
+	// ClaimDue leases up to limit due, pending messages for delivery.
+	// Rows claimed by another replica are skipped, not waited on.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.ScheduledMessage, error)
+
+	// MarkSent records a successful delivery.
+	MarkSent(ctx context.Context, id uuid.UUID, messageID int64) error
+
+	// MarkFailed records a delivery that was rejected and won't be retried.
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string) error
+}
+
+// OutboxRepository drains the transactional outbox. Rows are written by
+// other repositories inside their own transactions (see
+// CreateMessageParams.Outbox).
+type OutboxRepository interface {
+	// ClaimPending leases up to limit unsent events whose next attempt is
+	// due, oldest first. Rows claimed by another replica are skipped.
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+
+	// MarkSent records that events were published.
+	MarkSent(ctx context.Context, ids []int64) error
+
+	// MarkFailed records a failed publish and schedules the next attempt
+	// after retryIn.
+	MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration) error
+
+	// Release gives up claims on events without attempting them, so they
+	// can be claimed again right away.
+	Release(ctx context.Context, ids []int64) error
+
+	// PruneSent deletes events published more than olderThan ago and
+	// returns how many went.
+	PruneSent(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+// ReactionRepository handles emoji reactions on messages.
+// Callers are responsible for checking the message belongs to the right
+// channel and tenant — reactions are keyed by message ID only.
+type ReactionRepository interface {
+	// Add records a reaction. Returns false if the user had already reacted
+	// with this emoji (idempotent).
+	Add(ctx context.Context, messageID int64, userID uuid.UUID, emoji string) (bool, error)
+
+	// Remove deletes a reaction. Returns false if there was nothing to remove.
+	Remove(ctx context.Context, messageID int64, userID uuid.UUID, emoji string) (bool, error)
+
+	// Summaries aggregates reactions for a batch of messages in one query.
+	// Reacted is set relative to viewerID. Messages without reactions are
+	// absent from the map.
+	Summaries(ctx context.Context, messageIDs []int64, viewerID uuid.UUID) (map[int64][]models.ReactionSummary, error)
 }
 
 // UserRepository handles user data.
@@ -54,11 +294,39 @@ type UserRepository interface {
 
 	// GetByEmail returns a user by email. Returns nil, nil if not found.
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+
+	// CountInTenant returns how many of userIDs exist in the tenant.
+	CountInTenant(ctx context.Context, tenantID uuid.UUID, userIDs []uuid.UUID) (int, error)
 }
 
 // TenantRepository handles tenant (workspace) data.
 type TenantRepository interface {
 	Create(ctx context.Context, name string) (*models.Tenant, error)
+
+	// MaxUploadBytes returns the tenant's upload cap, or 0 if the tenant
+	// uses the server default.
+	MaxUploadBytes(ctx context.Context, tenantID uuid.UUID) (int64, error)
+}
+
+// FileRepository stores uploaded file metadata and message attachments.
+type FileRepository interface {
+	// Create records an uploaded file and returns it with CreatedAt populated.
+	Create(ctx context.Context, f *models.File) (*models.File, error)
+
+	// GetByID returns a file in the tenant. Returns nil, nil if not found
+	// or deleted along with its message.
+	GetByID(ctx context.Context, tenantID uuid.UUID, fileID uuid.UUID) (*models.File, error)
+
+	// GetByIDs returns the files in the tenant among ids, in no particular
+	// order. Missing and deleted IDs are simply absent from the result.
+	GetByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]models.File, error)
+
+	// ForMessages returns attachments for each message ID, in display order.
+	ForMessages(ctx context.Context, messageIDs []int64) (map[int64][]models.File, error)
+
+	// SetImageMetadata records an image's dimensions, blurhash and the
+	// thumbnail sizes that were stored.
+	SetImageMetadata(ctx context.Context, fileID uuid.UUID, width, height int, blurhash string, thumbnails []int) error
 }
 
 // SignupRepository atomically creates a tenant + user in one operation.
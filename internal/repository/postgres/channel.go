@@ -2,15 +2,26 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
 )
 
+// channelColumns is the SELECT/RETURNING list that scanChannel expects.
+const channelColumns = `id, tenant_id, name, is_private, kind, created_at, pins_admin_only,
+	topic, purpose, created_by, archived_at`
+
+// channelNameIndex enforces unique channel names per tenant (migration 018).
+const channelNameIndex = "idx_channels_tenant_name"
+
 type ChannelStore struct {
 	pool *pgxpool.Pool
 }
@@ -20,21 +31,35 @@ func NewChannelStore(pool *pgxpool.Pool) *ChannelStore {
 	return &ChannelStore{pool: pool}
 }
 
-func (s *ChannelStore) Create(ctx context.Context, tenantID uuid.UUID, name string, isPrivate bool) (*models.Channel, error) {
-	query := `
-		INSERT INTO channels (id, tenant_id, name, is_private, created_at)
-		VALUES (uuid_generate_v4(), $1, $2, $3, now())
-		RETURNING id, tenant_id, name, is_private, created_at`
-
-	var ch models.Channel
-	err := s.pool.QueryRow(ctx, query, tenantID, name, isPrivate).Scan(
+// scanChannel reads one row selected with channelColumns.
+func scanChannel(row pgx.Row, ch *models.Channel) error {
+	return row.Scan(
 		&ch.ID,
 		&ch.TenantID,
 		&ch.Name,
 		&ch.IsPrivate,
+		&ch.Kind,
 		&ch.CreatedAt,
+		&ch.PinsAdminOnly,
+		&ch.Topic,
+		&ch.Purpose,
+		&ch.CreatedBy,
+		&ch.ArchivedAt,
 	)
+}
+
+func (s *ChannelStore) Create(ctx context.Context, p repository.CreateChannelParams) (*models.Channel, error) {
+	query := `
+		INSERT INTO channels (id, tenant_id, name, is_private, pins_admin_only, created_by, created_at)
+		VALUES (uuid_generate_v4(), $1, $2, $3, $4, $5, now())
+		RETURNING ` + channelColumns
+
+	var ch models.Channel
+	err := scanChannel(s.pool.QueryRow(ctx, query, p.TenantID, p.Name, p.IsPrivate, p.PinsAdminOnly, p.CreatedBy), &ch)
 	if err != nil {
+		if isChannelNameConflict(err) {
+			return nil, repository.ErrChannelNameTaken
+		}
 		return nil, fmt.Errorf("insert channel: %w", err)
 	}
 	return &ch, nil
@@ -42,18 +67,12 @@ func (s *ChannelStore) Create(ctx context.Context, tenantID uuid.UUID, name stri
 
 func (s *ChannelStore) GetByID(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID) (*models.Channel, error) {
 	query := `
-		SELECT id, tenant_id, name, is_private, created_at
+		SELECT ` + channelColumns + `
 		FROM channels
 		WHERE id = $1 AND tenant_id = $2`
 
 	var ch models.Channel
-	err := s.pool.QueryRow(ctx, query, channelID, tenantID).Scan(
-		&ch.ID,
-		&ch.TenantID,
-		&ch.Name,
-		&ch.IsPrivate,
-		&ch.CreatedAt,
-	)
+	err := scanChannel(s.pool.QueryRow(ctx, query, channelID, tenantID), &ch)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -63,15 +82,71 @@ func (s *ChannelStore) GetByID(ctx context.Context, tenantID uuid.UUID, channelI
 	return &ch, nil
 }
 
-func (s *ChannelStore) ListByTenant(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]models.Channel, error) {
+func (s *ChannelStore) GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.Channel, error) {
+	query := `
+		SELECT ` + channelColumns + `
+		FROM channels
+		WHERE tenant_id = $1 AND name = $2 AND kind = 'channel'`
+
+	var ch models.Channel
+	err := scanChannel(s.pool.QueryRow(ctx, query, tenantID, name), &ch)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get channel by name: %w", err)
+	}
+	return &ch, nil
+}
+
+// isChannelNameConflict reports whether err is a unique violation on
+// channelNameIndex.
+func isChannelNameConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == channelNameIndex
+}
+
+func (s *ChannelStore) Update(ctx context.Context, tenantID, channelID uuid.UUID, u repository.ChannelUpdate) (*models.Channel, error) {
+	query := `
+		UPDATE channels SET
+			name = COALESCE($3, name),
+			topic = COALESCE($4, topic),
+			purpose = COALESCE($5, purpose),
+			archived_at = CASE
+				WHEN $6::boolean IS NULL THEN archived_at
+				WHEN $6 THEN COALESCE(archived_at, now())
+				ELSE NULL
+			END
+		WHERE id = $1 AND tenant_id = $2
+		RETURNING ` + channelColumns
+
+	var ch models.Channel
+	err := scanChannel(s.pool.QueryRow(ctx, query, channelID, tenantID, u.Name, u.Topic, u.Purpose, u.Archived), &ch)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		if isChannelNameConflict(err) {
+			return nil, repository.ErrChannelNameTaken
+		}
+		return nil, fmt.Errorf("update channel: %w", err)
+	}
+	return &ch, nil
+}
+
+func (s *ChannelStore) ListByTenant(ctx context.Context, tenantID, viewerID uuid.UUID, limit, offset int) ([]models.Channel, error) {
 	query := `
-		SELECT id, tenant_id, name, is_private, created_at
+		SELECT ` + channelColumns + `
 		FROM channels
-		WHERE tenant_id = $1
+		WHERE tenant_id = $1 AND kind = 'channel'
+		  AND (NOT is_private OR EXISTS (
+		    SELECT 1 FROM channel_members m
+		    WHERE m.channel_id = channels.id AND m.user_id = $2
+		  ))
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`
+		LIMIT $3 OFFSET $4`
 
-	rows, err := s.pool.Query(ctx, query, tenantID, limit, offset)
+	rows, err := s.pool.Query(ctx, query, tenantID, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("list channels: %w", err)
 	}
@@ -80,13 +155,7 @@ func (s *ChannelStore) ListByTenant(ctx context.Context, tenantID uuid.UUID, lim
 	channels := make([]models.Channel, 0)
 	for rows.Next() {
 		var ch models.Channel
-		if err := rows.Scan(
-			&ch.ID,
-			&ch.TenantID,
-			&ch.Name,
-			&ch.IsPrivate,
-			&ch.CreatedAt,
-		); err != nil {
+		if err := scanChannel(rows, &ch); err != nil {
 			return nil, fmt.Errorf("scan channel: %w", err)
 		}
 		channels = append(channels, ch)
@@ -97,3 +166,127 @@ func (s *ChannelStore) ListByTenant(ctx context.Context, tenantID uuid.UUID, lim
 
 	return channels, nil
 }
+
+func (s *ChannelStore) SetPrivate(ctx context.Context, tenantID, channelID, actorID uuid.UUID, private bool) (*models.Channel, bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin convert tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	// Lock the row so concurrent conversions are applied (and audited) one
+	// at a time.
+	var ch models.Channel
+	err = scanChannel(tx.QueryRow(ctx,
+		`SELECT `+channelColumns+` FROM channels
+		 WHERE id = $1 AND tenant_id = $2
+		 FOR UPDATE`,
+		channelID, tenantID,
+	), &ch)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("lock channel: %w", err)
+	}
+	if ch.IsPrivate == private {
+		return &ch, false, nil
+	}
+
+	err = scanChannel(tx.QueryRow(ctx,
+		`UPDATE channels SET is_private = $3
+		 WHERE id = $1 AND tenant_id = $2
+		 RETURNING `+channelColumns,
+		channelID, tenantID, private,
+	), &ch)
+	if err != nil {
+		return nil, false, fmt.Errorf("convert channel: %w", err)
+	}
+
+	if err := insertChannelAudit(ctx, tx, tenantID, channelID, actorID, "privacy_changed", map[string]any{"is_private": private}); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("commit convert tx: %w", err)
+	}
+	return &ch, true, nil
+}
+
+// insertChannelAudit appends a channel_audit_log row inside tx, so the
+// record commits or rolls back with the change it describes.
+func insertChannelAudit(ctx context.Context, tx pgx.Tx, tenantID, channelID, actorID uuid.UUID, action string, details map[string]any) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("marshal audit details: %w", err)
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO channel_audit_log (tenant_id, channel_id, actor_id, action, details)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		tenantID, channelID, actorID, action, data,
+	)
+	if err != nil {
+		return fmt.Errorf("insert channel audit: %w", err)
+	}
+	return nil
+}
+
+// FindOrCreateDM relies on the unique (tenant_id, dm_key) index: if two
+// requests race to open the same DM, one INSERT wins and the other falls
+// through to the SELECT and returns the winner's channel.
+func (s *ChannelStore) FindOrCreateDM(ctx context.Context, tenantID uuid.UUID, memberIDs []uuid.UUID) (*models.Channel, bool, error) {
+	keyParts := make([]string, len(memberIDs))
+	for i, id := range memberIDs {
+		keyParts[i] = id.String()
+	}
+	dmKey := strings.Join(keyParts, ",")
+
+	kind := models.ChannelKindDM
+	if len(memberIDs) > 2 {
+		kind = models.ChannelKindGroupDM
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin dm tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	var ch models.Channel
+	created := true
+	err = scanChannel(tx.QueryRow(ctx,
+		`INSERT INTO channels (id, tenant_id, name, is_private, kind, dm_key, created_at)
+		 VALUES (uuid_generate_v4(), $1, '', true, $2, $3, now())
+		 ON CONFLICT (tenant_id, dm_key) WHERE dm_key IS NOT NULL DO NOTHING
+		 RETURNING `+channelColumns,
+		tenantID, kind, dmKey,
+	), &ch)
+	if errors.Is(err, pgx.ErrNoRows) {
+		created = false
+		err = scanChannel(tx.QueryRow(ctx,
+			`SELECT `+channelColumns+` FROM channels
+			 WHERE tenant_id = $1 AND dm_key = $2`,
+			tenantID, dmKey,
+		), &ch)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("find or create dm: %w", err)
+	}
+
+	// Also runs for existing DMs: a participant who left gets re-added
+	// when the conversation is reopened, with the history already read.
+	_, err = tx.Exec(ctx,
+		`INSERT INTO channel_members (channel_id, user_id, role, last_read_id)
+		 SELECT $1, unnest($2::uuid[]), 'member', `+channelHeadID+`
+		 ON CONFLICT (channel_id, user_id) DO NOTHING`,
+		ch.ID, memberIDs,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("add dm members: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("commit dm tx: %w", err)
+	}
+	return &ch, created, nil
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lalith-99/echostream/internal/models"
+)
+
+// fileColumns is the SELECT/RETURNING list that fileFields expects.
+const fileColumns = `id, tenant_id, channel_id, uploader_id, name, content_type, size_bytes, storage_key, created_at,
+	width, height, blurhash, thumbnail_sizes`
+
+type FileStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewFileStore returns a Postgres-backed file metadata store.
+func NewFileStore(pool *pgxpool.Pool) *FileStore {
+	return &FileStore{pool: pool}
+}
+
+// fileFields returns scan targets in fileColumns order.
+func fileFields(f *models.File) []any {
+	return []any{
+		&f.ID,
+		&f.TenantID,
+		&f.ChannelID,
+		&f.UploaderID,
+		&f.Name,
+		&f.ContentType,
+		&f.SizeBytes,
+		&f.StorageKey,
+		&f.CreatedAt,
+		&f.Width,
+		&f.Height,
+		&f.Blurhash,
+		&f.Thumbnails,
+	}
+}
+
+func (s *FileStore) Create(ctx context.Context, f *models.File) (*models.File, error) {
+	query := `
+		INSERT INTO files (id, tenant_id, channel_id, uploader_id, name, content_type, size_bytes, storage_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+		RETURNING ` + fileColumns
+
+	var out models.File
+	err := s.pool.QueryRow(ctx, query,
+		f.ID, f.TenantID, f.ChannelID, f.UploaderID, f.Name, f.ContentType, f.SizeBytes, f.StorageKey,
+	).Scan(fileFields(&out)...)
+	if err != nil {
+		return nil, fmt.Errorf("insert file: %w", err)
+	}
+	return &out, nil
+}
+
+func (s *FileStore) GetByID(ctx context.Context, tenantID uuid.UUID, fileID uuid.UUID) (*models.File, error) {
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL`
+
+	var f models.File
+	err := s.pool.QueryRow(ctx, query, fileID, tenantID).Scan(fileFields(&f)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get file: %w", err)
+	}
+	return &f, nil
+}
+
+func (s *FileStore) GetByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]models.File, error) {
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE tenant_id = $1 AND id = ANY($2) AND deleted_at IS NULL`
+
+	rows, err := s.pool.Query(ctx, query, tenantID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get files: %w", err)
+	}
+	defer rows.Close()
+
+	files := make([]models.File, 0, len(ids))
+	for rows.Next() {
+		var f models.File
+		if err := rows.Scan(fileFields(&f)...); err != nil {
+			return nil, fmt.Errorf("scan file: %w", err)
+		}
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate files: %w", err)
+	}
+
+	return files, nil
+}
+
+func (s *FileStore) ForMessages(ctx context.Context, messageIDs []int64) (map[int64][]models.File, error) {
+	query := `
+		SELECT ma.message_id, ` + prefixColumns("f", fileColumns) + `
+		FROM message_attachments ma
+		JOIN files f ON f.id = ma.file_id
+		WHERE ma.message_id = ANY($1)
+		ORDER BY ma.message_id, ma.position`
+
+	rows, err := s.pool.Query(ctx, query, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[int64][]models.File)
+	for rows.Next() {
+		var msgID int64
+		var f models.File
+		if err := rows.Scan(append([]any{&msgID}, fileFields(&f)...)...); err != nil {
+			return nil, fmt.Errorf("scan attachment: %w", err)
+		}
+		out[msgID] = append(out[msgID], f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate attachments: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *FileStore) SetImageMetadata(ctx context.Context, fileID uuid.UUID, width, height int, blurhash string, thumbnails []int) error {
+	query := `
+		UPDATE files
+		SET width = $2, height = $3, blurhash = $4, thumbnail_sizes = $5
+		WHERE id = $1`
+
+	if _, err := s.pool.Exec(ctx, query, fileID, width, height, blurhash, thumbnails); err != nil {
+		return fmt.Errorf("set image metadata: %w", err)
+	}
+	return nil
+}
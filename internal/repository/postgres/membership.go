@@ -2,13 +2,23 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lalith-99/echostream/internal/models"
 )
 
+// channelHeadID is the newest message ID in channel $1 (0 if empty).
+// max(id) is a single probe of idx_messages_channel_id.
+const channelHeadID = `(SELECT COALESCE(max(id), 0) FROM messages WHERE channel_id = $1)`
+
+// maxUnreadCount caps per-channel unread counting so a long-abandoned
+// channel doesn't scan its whole history; clients show "999+".
+const maxUnreadCount = 1000
+
 type MembershipStore struct {
 	pool *pgxpool.Pool
 }
@@ -18,30 +28,31 @@ func NewMembershipStore(pool *pgxpool.Pool) *MembershipStore {
 	return &MembershipStore{pool: pool}
 }
 
-func (s *MembershipStore) AddMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID, role string) error {
+func (s *MembershipStore) AddMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID, role string) (bool, error) {
 	// ON CONFLICT DO NOTHING makes this idempotent.
+	// New members start with everything already posted marked as read.
 	query := `
-		INSERT INTO channel_members (channel_id, user_id, role)
-		VALUES ($1, $2, $3)
+		INSERT INTO channel_members (channel_id, user_id, role, last_read_id)
+		VALUES ($1, $2, $3, ` + channelHeadID + `)
 		ON CONFLICT (channel_id, user_id) DO NOTHING`
 
-	_, err := s.pool.Exec(ctx, query, channelID, userID, role)
+	tag, err := s.pool.Exec(ctx, query, channelID, userID, role)
 	if err != nil {
-		return fmt.Errorf("add member: %w", err)
+		return false, fmt.Errorf("add member: %w", err)
 	}
-	return nil
+	return tag.RowsAffected() > 0, nil
 }
 
-func (s *MembershipStore) RemoveMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID) error {
+func (s *MembershipStore) RemoveMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID) (bool, error) {
 	query := `
 		DELETE FROM channel_members
 		WHERE channel_id = $1 AND user_id = $2`
 
-	_, err := s.pool.Exec(ctx, query, channelID, userID)
+	tag, err := s.pool.Exec(ctx, query, channelID, userID)
 	if err != nil {
-		return fmt.Errorf("remove member: %w", err)
+		return false, fmt.Errorf("remove member: %w", err)
 	}
-	return nil
+	return tag.RowsAffected() > 0, nil
 }
 
 func (s *MembershipStore) ListMembers(ctx context.Context, channelID uuid.UUID, limit, offset int) ([]models.ChannelMember, error) {
@@ -73,6 +84,33 @@ func (s *MembershipStore) ListMembers(ctx context.Context, channelID uuid.UUID,
 	return members, nil
 }
 
+func (s *MembershipStore) ListMemberIDs(ctx context.Context, channelID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT user_id
+		FROM channel_members
+		WHERE channel_id = $1`
+
+	rows, err := s.pool.Query(ctx, query, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("list member ids: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan member id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate member ids: %w", err)
+	}
+
+	return ids, nil
+}
+
 func (s *MembershipStore) IsMember(ctx context.Context, channelID uuid.UUID, userID uuid.UUID) (bool, error) {
 	query := `
 		SELECT EXISTS (
@@ -87,3 +125,194 @@ func (s *MembershipStore) IsMember(ctx context.Context, channelID uuid.UUID, use
 	}
 	return exists, nil
 }
+
+func (s *MembershipStore) GetRole(ctx context.Context, channelID uuid.UUID, userID uuid.UUID) (string, error) {
+	query := `
+		SELECT COALESCE(role, '')
+		FROM channel_members
+		WHERE channel_id = $1 AND user_id = $2`
+
+	var role string
+	err := s.pool.QueryRow(ctx, query, channelID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get member role: %w", err)
+	}
+	return role, nil
+}
+
+func (s *MembershipStore) UpdateRole(ctx context.Context, tenantID, channelID, actorID, userID uuid.UUID, role string) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin role tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE channel_members SET role = $3
+		 WHERE channel_id = $1 AND user_id = $2`,
+		channelID, userID, role,
+	)
+	if err != nil {
+		return false, fmt.Errorf("update member role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	details := map[string]any{"user_id": userID, "role": role}
+	if err := insertChannelAudit(ctx, tx, tenantID, channelID, actorID, "role_changed", details); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit role tx: %w", err)
+	}
+	return true, nil
+}
+
+// TransferOwnership demotes the current owner to admin before promoting
+// the new one, since idx_channel_members_owner allows one owner at a time.
+func (s *MembershipStore) TransferOwnership(ctx context.Context, tenantID, channelID, fromID, toID uuid.UUID) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin transfer tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE channel_members SET role = 'admin'
+		 WHERE channel_id = $1 AND user_id = $2 AND role = 'owner'`,
+		channelID, fromID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("demote owner: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	tag, err = tx.Exec(ctx,
+		`UPDATE channel_members SET role = 'owner'
+		 WHERE channel_id = $1 AND user_id = $2`,
+		channelID, toID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("promote owner: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	details := map[string]any{"from": fromID, "to": toID}
+	if err := insertChannelAudit(ctx, tx, tenantID, channelID, fromID, "ownership_transferred", details); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit transfer tx: %w", err)
+	}
+	return true, nil
+}
+
+func (s *MembershipStore) Kick(ctx context.Context, tenantID, channelID, actorID, userID uuid.UUID) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin kick tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM channel_members
+		 WHERE channel_id = $1 AND user_id = $2`,
+		channelID, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("kick member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	details := map[string]any{"user_id": userID}
+	if err := insertChannelAudit(ctx, tx, tenantID, channelID, actorID, "member_removed", details); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit kick tx: %w", err)
+	}
+	return true, nil
+}
+
+func (s *MembershipStore) MarkRead(ctx context.Context, channelID uuid.UUID, userID uuid.UUID, messageID int64) (bool, error) {
+	// The cursor only moves forward, so a stale device can't roll it back.
+	query := `
+		UPDATE channel_members
+		SET last_read_id = $3
+		WHERE channel_id = $1 AND user_id = $2 AND last_read_id < $3`
+
+	tag, err := s.pool.Exec(ctx, query, channelID, userID, messageID)
+	if err != nil {
+		return false, fmt.Errorf("mark read: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Unreads counts, per channel, top-level messages from other users past
+// the read cursor. Each count is a range scan of idx_messages_channel_id
+// starting at last_read_id, capped at maxUnreadCount.
+//
+// A message counts as a mention if it has a message_mentions row for the
+// user. Every unread message in a DM or group DM is a mention.
+func (s *MembershipStore) Unreads(ctx context.Context, tenantID uuid.UUID, userID uuid.UUID) ([]models.ChannelUnread, error) {
+	query := `
+		SELECT cm.channel_id, cm.last_read_id, u.unread, u.mentions
+		FROM channel_members cm
+		JOIN channels c ON c.id = cm.channel_id
+		CROSS JOIN LATERAL (
+			SELECT
+				count(*) AS unread,
+				count(*) FILTER (
+					WHERE c.kind <> 'channel'
+					   OR EXISTS (
+						SELECT 1 FROM message_mentions mm
+						WHERE mm.message_id = m.id AND mm.user_id = $2
+					   )
+				) AS mentions
+			FROM (
+				SELECT id
+				FROM messages
+				WHERE channel_id = cm.channel_id
+				  AND id > cm.last_read_id
+				  AND parent_id IS NULL
+				  AND deleted_at IS NULL
+				  AND sender_id <> $2
+				LIMIT $3
+			) m
+		) u
+		WHERE c.tenant_id = $1 AND cm.user_id = $2
+		ORDER BY cm.channel_id`
+
+	rows, err := s.pool.Query(ctx, query, tenantID, userID, maxUnreadCount)
+	if err != nil {
+		return nil, fmt.Errorf("list unreads: %w", err)
+	}
+	defer rows.Close()
+
+	unreads := make([]models.ChannelUnread, 0)
+	for rows.Next() {
+		var u models.ChannelUnread
+		if err := rows.Scan(&u.ChannelID, &u.LastReadID, &u.UnreadCount, &u.MentionCount); err != nil {
+			return nil, fmt.Errorf("scan unread: %w", err)
+		}
+		unreads = append(unreads, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate unreads: %w", err)
+	}
+
+	return unreads, nil
+}
@@ -2,13 +2,21 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
 )
 
+// messageColumns is the SELECT/RETURNING list that scanMessage expects.
+const messageColumns = `id, channel_id, sender_id, body, created_at, edited_at, deleted_at, deleted_by,
+	parent_id, reply_count, last_reply_at, client_msg_id`
+
 type MessageStore struct {
 	pool *pgxpool.Pool
 }
@@ -18,50 +26,201 @@ func NewMessageStore(pool *pgxpool.Pool) *MessageStore {
 	return &MessageStore{pool: pool}
 }
 
-func (s *MessageStore) Create(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, senderID uuid.UUID, body string) (*models.Message, error) {
-	query := `
-		INSERT INTO messages (tenant_id, channel_id, sender_id, body, created_at)
-		VALUES ($1, $2, $3, $4, now())
-		RETURNING id, channel_id, sender_id, body, created_at`
+// scanMessage reads one row selected with messageColumns.
+func scanMessage(row pgx.Row, msg *models.Message) error {
+	return row.Scan(messageFields(msg)...)
+}
 
-	var msg models.Message
-	err := s.pool.QueryRow(ctx, query, tenantID, channelID, senderID, body).Scan(
+// messageFields returns scan targets in messageColumns order, for queries
+// that select extra columns after the message.
+func messageFields(msg *models.Message) []any {
+	return []any{
 		&msg.ID,
 		&msg.ChannelID,
 		&msg.SenderID,
 		&msg.Body,
 		&msg.CreatedAt,
-	)
+		&msg.EditedAt,
+		&msg.DeletedAt,
+		&msg.DeletedBy,
+		&msg.ParentID,
+		&msg.ReplyCount,
+		&msg.LastReplyAt,
+		&msg.ClientMsgID,
+	}
+}
+
+// queryRower is satisfied by both *pgxpool.Pool and pgx.Tx.
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (s *MessageStore) Create(ctx context.Context, p repository.CreateMessageParams) (*models.Message, bool, error) {
+	if p.ParentID == 0 && len(p.Mentions) == 0 && len(p.AttachmentIDs) == 0 && p.Outbox == nil {
+		return insertMessage(ctx, s.pool, p)
+	}
+
+	// Replies, mentions, attachments and outbox events write more than one
+	// row; do it atomically so reply_count never drifts and a message never
+	// loses its mentions, files or real-time delivery.
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("insert message: %w", err)
+		return nil, false, fmt.Errorf("begin message tx: %w", err)
 	}
-	return &msg, nil
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	msg, created, err := insertMessage(ctx, tx, p)
+	if err != nil || !created {
+		return msg, created, err
+	}
+
+	if p.ParentID > 0 {
+		_, err = tx.Exec(ctx,
+			`UPDATE messages SET reply_count = reply_count + 1, last_reply_at = $1
+			 WHERE id = $2`,
+			msg.CreatedAt, p.ParentID,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf("bump reply count: %w", err)
+		}
+	}
+
+	if err := insertMentions(ctx, tx, msg.ID, p.Mentions); err != nil {
+		return nil, false, err
+	}
+
+	if len(p.AttachmentIDs) > 0 {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO message_attachments (message_id, file_id, position)
+			 SELECT $1, a.file_id, a.ord - 1
+			 FROM unnest($2::uuid[]) WITH ORDINALITY AS a(file_id, ord)`,
+			msg.ID, p.AttachmentIDs,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf("insert attachments: %w", err)
+		}
+	}
+
+	if p.Outbox != nil {
+		events, err := p.Outbox(msg)
+		if err != nil {
+			return nil, false, fmt.Errorf("build outbox events: %w", err)
+		}
+		if err := insertOutbox(ctx, tx, events); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("commit message tx: %w", err)
+	}
+	return msg, true, nil
+}
+
+// insertMessage writes one message. A repeated client_msg_id hits the
+// partial unique index, inserts nothing, and the original row is read back.
+func insertMessage(ctx context.Context, q queryRower, p repository.CreateMessageParams) (*models.Message, bool, error) {
+	var parentID *int64
+	if p.ParentID > 0 {
+		parentID = &p.ParentID
+	}
+	var clientMsgID *string
+	if p.ClientMsgID != "" {
+		clientMsgID = &p.ClientMsgID
+	}
+
+	var msg models.Message
+	err := scanMessage(q.QueryRow(ctx,
+		`INSERT INTO messages (tenant_id, channel_id, sender_id, body, parent_id, client_msg_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, now())
+		 ON CONFLICT (sender_id, channel_id, client_msg_id) WHERE client_msg_id IS NOT NULL DO NOTHING
+		 RETURNING `+messageColumns,
+		p.TenantID, p.ChannelID, p.SenderID, p.Body, parentID, clientMsgID,
+	), &msg)
+	if err == nil {
+		return &msg, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("insert message: %w", err)
+	}
+
+	err = scanMessage(q.QueryRow(ctx,
+		`SELECT `+messageColumns+` FROM messages
+		 WHERE tenant_id = $1 AND channel_id = $2 AND sender_id = $3 AND client_msg_id = $4`,
+		p.TenantID, p.ChannelID, p.SenderID, p.ClientMsgID,
+	), &msg)
+	if err != nil {
+		return nil, false, fmt.Errorf("get message by client_msg_id: %w", err)
+	}
+	return &msg, false, nil
 }
 
 func (s *MessageStore) ListByChannel(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, before int64, limit int) ([]models.Message, error) {
-	// Cursor-based pagination: before=0 means latest, before=N means older than ID N.
 	// tenant_id in WHERE clause = defense-in-depth: even if someone guesses a
 	// channel UUID, the query won't return rows from another tenant.
-	var query string
-	var args []any
+	// Thread replies live under their parent, not in channel history.
+	return s.list(ctx,
+		`tenant_id = $1 AND channel_id = $2 AND parent_id IS NULL`,
+		[]any{tenantID, channelID},
+		before, limit,
+	)
+}
+
+func (s *MessageStore) ListAfter(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, after int64, limit int) ([]models.Message, error) {
+	// Walks idx_messages_channel_id forwards from the cursor.
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE tenant_id = $1 AND channel_id = $2 AND parent_id IS NULL AND id > $3
+		ORDER BY id ASC
+		LIMIT $4`
+
+	rows, err := s.pool.Query(ctx, query, tenantID, channelID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list messages after: %w", err)
+	}
+	defer rows.Close()
 
+	messages := make([]models.Message, 0)
+	for rows.Next() {
+		var msg models.Message
+		if err := scanMessage(rows, &msg); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (s *MessageStore) ListReplies(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, parentID int64, before int64, limit int) ([]models.Message, error) {
+	return s.list(ctx,
+		`tenant_id = $1 AND channel_id = $2 AND parent_id = $3`,
+		[]any{tenantID, channelID, parentID},
+		before, limit,
+	)
+}
+
+// list runs a newest-first page query over messages matching filter.
+// filter must reference its args as $1..$len(args).
+//
+// Cursor-based pagination: before=0 means latest, before=N means older than ID N.
+func (s *MessageStore) list(ctx context.Context, filter string, args []any, before int64, limit int) ([]models.Message, error) {
 	if before > 0 {
-		query = `
-			SELECT id, channel_id, sender_id, body, created_at
-			FROM messages
-			WHERE tenant_id = $1 AND channel_id = $2 AND id < $3
-			ORDER BY id DESC
-			LIMIT $4`
-		args = []any{tenantID, channelID, before, limit}
-	} else {
-		query = `
-			SELECT id, channel_id, sender_id, body, created_at
-			FROM messages
-			WHERE tenant_id = $1 AND channel_id = $2
-			ORDER BY id DESC
-			LIMIT $3`
-		args = []any{tenantID, channelID, limit}
+		args = append(args, before)
+		filter += fmt.Sprintf(" AND id < $%d", len(args))
 	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM messages
+		WHERE %s
+		ORDER BY id DESC
+		LIMIT $%d`, messageColumns, filter, len(args))
 
 	rows, err := s.pool.Query(ctx, query, args...)
 	if err != nil {
@@ -72,13 +231,7 @@ func (s *MessageStore) ListByChannel(ctx context.Context, tenantID uuid.UUID, ch
 	messages := make([]models.Message, 0)
 	for rows.Next() {
 		var msg models.Message
-		if err := rows.Scan(
-			&msg.ID,
-			&msg.ChannelID,
-			&msg.SenderID,
-			&msg.Body,
-			&msg.CreatedAt,
-		); err != nil {
+		if err := scanMessage(rows, &msg); err != nil {
 			return nil, fmt.Errorf("scan message: %w", err)
 		}
 		messages = append(messages, msg)
@@ -89,3 +242,364 @@ func (s *MessageStore) ListByChannel(ctx context.Context, tenantID uuid.UUID, ch
 
 	return messages, nil
 }
+
+func (s *MessageStore) ListMentions(ctx context.Context, tenantID uuid.UUID, userID uuid.UUID, before int64, limit int) ([]models.MentionResult, error) {
+	// Walks idx_message_mentions_user_id newest first; the membership
+	// EXISTS hides channels the user has since left.
+	args := []any{tenantID, userID}
+	cursor := ""
+	if before > 0 {
+		args = append(args, before)
+		cursor = fmt.Sprintf(" AND mm.message_id < $%d", len(args))
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s, c.name, mm.kind
+		FROM message_mentions mm
+		JOIN messages m ON m.id = mm.message_id
+		JOIN channels c ON c.id = m.channel_id
+		WHERE mm.user_id = $2%s
+		  AND m.tenant_id = $1
+		  AND m.deleted_at IS NULL
+		  AND EXISTS (SELECT 1 FROM channel_members cm WHERE cm.channel_id = m.channel_id AND cm.user_id = $2)
+		ORDER BY mm.message_id DESC
+		LIMIT $%d`, prefixColumns("m", messageColumns), cursor, len(args))
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list mentions: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.MentionResult, 0)
+	for rows.Next() {
+		var r models.MentionResult
+		if err := rows.Scan(append(messageFields(&r.Message), &r.ChannelName, &r.Kind)...); err != nil {
+			return nil, fmt.Errorf("scan mention: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate mentions: %w", err)
+	}
+
+	return results, nil
+}
+
+// prefixColumns qualifies a column list like messageColumns with a table
+// alias, for joins where bare names would be ambiguous.
+func prefixColumns(alias, columns string) string {
+	cols := strings.Split(columns, ",")
+	for i, c := range cols {
+		cols[i] = alias + "." + strings.TrimSpace(c)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// Search builds one query from whichever filters are set. Every filter is
+// bound as a parameter — user input never reaches the SQL text.
+//
+// Visibility mirrors MembershipRepository.IsMember, but as an EXISTS in the
+// same query so we don't check channels one by one.
+func (s *MessageStore) Search(ctx context.Context, tenantID uuid.UUID, viewerID uuid.UUID, q repository.MessageSearch, before int64, limit int) ([]models.SearchResult, error) {
+	conds := []string{
+		"m.tenant_id = $1",
+		"m.deleted_at IS NULL",
+		"EXISTS (SELECT 1 FROM channel_members cm WHERE cm.channel_id = m.channel_id AND cm.user_id = $2)",
+	}
+	args := []any{tenantID, viewerID}
+	where := func(cond string, v any) int {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+		return len(args)
+	}
+
+	textArg := 0
+	if q.Text != "" {
+		textArg = where("m.search_vector @@ websearch_to_tsquery('english', $%d)", q.Text)
+	}
+	if q.ChannelID != uuid.Nil {
+		where("m.channel_id = $%d", q.ChannelID)
+	}
+	if q.ChannelName != "" {
+		where("lower(c.name) = lower($%d)", q.ChannelName)
+	}
+	if q.SenderID != uuid.Nil {
+		where("m.sender_id = $%d", q.SenderID)
+	}
+	if q.Sender != "" {
+		where(`EXISTS (SELECT 1 FROM users u WHERE u.id = m.sender_id
+			AND (lower(u.email) = lower($%[1]d) OR lower(u.display_name) = lower($%[1]d)))`, q.Sender)
+	}
+	if !q.Before.IsZero() {
+		where("m.created_at < $%d", q.Before)
+	}
+	if !q.After.IsZero() {
+		where("m.created_at >= $%d", q.After)
+	}
+	if before > 0 {
+		where("m.id < $%d", before)
+	}
+	args = append(args, limit)
+	limitArg := len(args)
+
+	// Snippets are HTML: the body is escaped before <mark> tags go in, so
+	// clients can render them as-is. Without search text there's nothing to
+	// highlight; show the start of the body.
+	snippet := escapeHTML("left(body, 200)")
+	if textArg > 0 {
+		snippet = fmt.Sprintf(`ts_headline('english', %s, websearch_to_tsquery('english', $%d),
+			'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5')`, escapeHTML("body"), textArg)
+	}
+
+	// The inner query finds one page of matches via the GIN and id indexes;
+	// ts_headline is expensive, so it only runs on that page.
+	query := fmt.Sprintf(`
+		SELECT %s, channel_name, %s
+		FROM (
+			SELECT m.*, c.name AS channel_name
+			FROM messages m
+			JOIN channels c ON c.id = m.channel_id
+			WHERE %s
+			ORDER BY m.id DESC
+			LIMIT $%d
+		) page
+		ORDER BY id DESC`, messageColumns, snippet, strings.Join(conds, " AND "), limitArg)
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search messages: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0)
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(append(messageFields(&r.Message), &r.ChannelName, &r.Snippet)...); err != nil {
+			return nil, fmt.Errorf("scan search result: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// escapeHTML wraps a SQL text expression so that &, < and > come out as
+// HTML entities.
+func escapeHTML(expr string) string {
+	return "replace(replace(replace(" + expr + ", '&', '&amp;'), '<', '&lt;'), '>', '&gt;')"
+}
+
+func (s *MessageStore) GetByID(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, messageID int64) (*models.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE id = $1 AND tenant_id = $2 AND channel_id = $3`
+
+	var msg models.Message
+	err := scanMessage(s.pool.QueryRow(ctx, query, messageID, tenantID, channelID), &msg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+	return &msg, nil
+}
+
+// insertMentions stores a message's resolved mentions.
+func insertMentions(ctx context.Context, tx pgx.Tx, messageID int64, mentions []models.Mention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	userIDs := make([]uuid.UUID, len(mentions))
+	kinds := make([]string, len(mentions))
+	for i, m := range mentions {
+		userIDs[i], kinds[i] = m.UserID, m.Kind
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO message_mentions (message_id, user_id, kind)
+		 SELECT $1, u.user_id, u.kind
+		 FROM unnest($2::uuid[], $3::text[]) AS u(user_id, kind)
+		 ON CONFLICT (message_id, user_id) DO NOTHING`,
+		messageID, userIDs, kinds,
+	)
+	if err != nil {
+		return fmt.Errorf("insert mentions: %w", err)
+	}
+	return nil
+}
+
+// replaceMentions swaps a message's mentions for a new set and returns the
+// ones whose user wasn't mentioned before.
+func replaceMentions(ctx context.Context, tx pgx.Tx, messageID int64, mentions []models.Mention) ([]models.Mention, error) {
+	rows, err := tx.Query(ctx,
+		`DELETE FROM message_mentions WHERE message_id = $1 RETURNING user_id`,
+		messageID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("delete mentions: %w", err)
+	}
+	defer rows.Close()
+
+	previous := make(map[uuid.UUID]struct{})
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan mention: %w", err)
+		}
+		previous[id] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate mentions: %w", err)
+	}
+
+	if err := insertMentions(ctx, tx, messageID, mentions); err != nil {
+		return nil, err
+	}
+
+	var added []models.Mention
+	for _, m := range mentions {
+		if _, ok := previous[m.UserID]; !ok {
+			added = append(added, m)
+		}
+	}
+	return added, nil
+}
+
+// Edit archives the current body into message_edits, writes the new one
+// and replaces the message's mentions.
+//
+// The row is locked with FOR UPDATE so two concurrent edits can't both
+// archive the same "previous" body — the second waits and archives the
+// first one's result instead.
+func (s *MessageStore) Edit(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, messageID int64, editorID uuid.UUID, body string, mentions []models.Mention) (*models.Message, []models.Mention, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin edit tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	var previous string
+	err = tx.QueryRow(ctx,
+		`SELECT body FROM messages
+		 WHERE id = $1 AND tenant_id = $2 AND channel_id = $3 AND deleted_at IS NULL
+		 FOR UPDATE`,
+		messageID, tenantID, channelID,
+	).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("lock message: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO message_edits (message_id, editor_id, previous_body, edited_at)
+		 VALUES ($1, $2, $3, now())`,
+		messageID, editorID, previous,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("insert message edit: %w", err)
+	}
+
+	var msg models.Message
+	err = scanMessage(tx.QueryRow(ctx,
+		`UPDATE messages SET body = $1, edited_at = now()
+		 WHERE id = $2
+		 RETURNING `+messageColumns,
+		body, messageID,
+	), &msg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("update message: %w", err)
+	}
+
+	added, err := replaceMentions(ctx, tx, messageID, mentions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("commit edit tx: %w", err)
+	}
+	return &msg, added, nil
+}
+
+// SoftDelete tombstones a message and removes its edit history in one
+// transaction, so no copy of the deleted text is left behind.
+func (s *MessageStore) SoftDelete(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID, messageID int64, deletedBy uuid.UUID) (*models.Message, bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin delete tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	var msg models.Message
+	err = scanMessage(tx.QueryRow(ctx,
+		`UPDATE messages SET body = '', deleted_at = now(), deleted_by = $1
+		 WHERE id = $2 AND tenant_id = $3 AND channel_id = $4 AND deleted_at IS NULL
+		 RETURNING `+messageColumns,
+		deletedBy, messageID, tenantID, channelID,
+	), &msg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("tombstone message: %w", err)
+	}
+
+	if msg.ParentID != nil {
+		// Undo the reply's bump; last_reply_at falls back to the newest
+		// reply still standing, or NULL if there is none.
+		_, err = tx.Exec(ctx,
+			`UPDATE messages p
+			 SET reply_count = GREATEST(p.reply_count - 1, 0),
+			     last_reply_at = (
+			       SELECT max(r.created_at) FROM messages r
+			       WHERE r.parent_id = p.id AND r.deleted_at IS NULL
+			     )
+			 WHERE p.id = $1`,
+			*msg.ParentID,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf("drop reply count: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM message_edits WHERE message_id = $1`, messageID); err != nil {
+		return nil, false, fmt.Errorf("delete message edits: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM message_pins WHERE message_id = $1`, messageID)
+	if err != nil {
+		return nil, false, fmt.Errorf("delete message pin: %w", err)
+	}
+
+	// Detach the message's files; a file no other message uses is deleted
+	// with it and can no longer be downloaded.
+	_, err = tx.Exec(ctx,
+		`WITH detached AS (
+		   DELETE FROM message_attachments WHERE message_id = $1
+		   RETURNING file_id
+		 )
+		 UPDATE files f SET deleted_at = now()
+		 WHERE f.id IN (SELECT file_id FROM detached)
+		   AND NOT EXISTS (
+		     SELECT 1 FROM message_attachments ma
+		     WHERE ma.file_id = f.id AND ma.message_id <> $1
+		   )`,
+		messageID,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("delete message attachments: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("commit delete tx: %w", err)
+	}
+	return &msg, tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lalith-99/echostream/internal/models"
+)
+
+type OutboxStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewOutboxStore returns a Postgres-backed outbox store.
+func NewOutboxStore(pool *pgxpool.Pool) *OutboxStore {
+	return &OutboxStore{pool: pool}
+}
+
+// insertOutbox writes events inside the caller's transaction.
+func insertOutbox(ctx context.Context, tx pgx.Tx, events []models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	topics := make([]string, len(events))
+	payloads := make([][]byte, len(events))
+	for i, ev := range events {
+		topics[i], payloads[i] = ev.Topic, ev.Payload
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO outbox (topic, payload)
+		 SELECT e.topic, e.payload
+		 FROM unnest($1::text[], $2::bytea[]) WITH ORDINALITY AS e(topic, payload, ord)
+		 ORDER BY e.ord`,
+		topics, payloads,
+	)
+	if err != nil {
+		return fmt.Errorf("insert outbox events: %w", err)
+	}
+	return nil
+}
+
+// ClaimPending takes a lease on due rows in one statement, like
+// ScheduledMessageStore.ClaimDue.
+func (s *OutboxStore) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	query := `
+		UPDATE outbox
+		SET claimed_until = now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at IS NULL AND next_attempt_at <= now() AND ` + unclaimed + `
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, topic, payload, attempts`
+
+	rows, err := s.pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var ev models.OutboxEvent
+		if err := rows.Scan(&ev.ID, &ev.Topic, &ev.Payload, &ev.Attempts); err != nil {
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate outbox events: %w", err)
+	}
+	// UPDATE ... RETURNING doesn't keep the subquery's order.
+	slices.SortFunc(events, func(a, b models.OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+func (s *OutboxStore) MarkSent(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `
+		UPDATE outbox
+		SET sent_at = now(), claimed_until = NULL
+		WHERE id = ANY($1)`
+
+	if _, err := s.pool.Exec(ctx, query, ids); err != nil {
+		return fmt.Errorf("mark outbox events sent: %w", err)
+	}
+	return nil
+}
+
+func (s *OutboxStore) MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2,
+		    next_attempt_at = now() + make_interval(secs => $3), claimed_until = NULL
+		WHERE id = $1`
+
+	if _, err := s.pool.Exec(ctx, query, id, reason, retryIn.Seconds()); err != nil {
+		return fmt.Errorf("mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+func (s *OutboxStore) Release(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `
+		UPDATE outbox
+		SET claimed_until = NULL
+		WHERE id = ANY($1) AND sent_at IS NULL`
+
+	if _, err := s.pool.Exec(ctx, query, ids); err != nil {
+		return fmt.Errorf("release outbox events: %w", err)
+	}
+	return nil
+}
+
+func (s *OutboxStore) PruneSent(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `DELETE FROM outbox WHERE sent_at < now() - make_interval(secs => $1)`
+
+	tag, err := s.pool.Exec(ctx, query, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("prune outbox: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
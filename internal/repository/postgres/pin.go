@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lalith-99/echostream/internal/models"
+)
+
+type PinStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPinStore returns a Postgres-backed pin store.
+func NewPinStore(pool *pgxpool.Pool) *PinStore {
+	return &PinStore{pool: pool}
+}
+
+func (s *PinStore) Pin(ctx context.Context, channelID uuid.UUID, messageID int64, pinnedBy uuid.UUID) (bool, error) {
+	// Same idempotency trick as reactions: only a new pin is broadcast.
+	query := `
+		INSERT INTO message_pins (message_id, channel_id, pinned_by, pinned_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (message_id) DO NOTHING`
+
+	tag, err := s.pool.Exec(ctx, query, messageID, channelID, pinnedBy)
+	if err != nil {
+		return false, fmt.Errorf("pin message: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *PinStore) Unpin(ctx context.Context, channelID uuid.UUID, messageID int64) (bool, error) {
+	query := `
+		DELETE FROM message_pins
+		WHERE message_id = $1 AND channel_id = $2`
+
+	tag, err := s.pool.Exec(ctx, query, messageID, channelID)
+	if err != nil {
+		return false, fmt.Errorf("unpin message: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *PinStore) List(ctx context.Context, tenantID uuid.UUID, channelID uuid.UUID) ([]models.PinnedMessage, error) {
+	// SoftDelete drops pins, so deleted_at is only a belt-and-braces filter.
+	query := `
+		SELECT ` + prefixColumns("m", messageColumns) + `, p.pinned_by, p.pinned_at
+		FROM message_pins p
+		JOIN messages m ON m.id = p.message_id
+		WHERE p.channel_id = $1 AND m.tenant_id = $2 AND m.deleted_at IS NULL
+		ORDER BY p.pinned_at DESC`
+
+	rows, err := s.pool.Query(ctx, query, channelID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list pins: %w", err)
+	}
+	defer rows.Close()
+
+	pins := make([]models.PinnedMessage, 0)
+	for rows.Next() {
+		var p models.PinnedMessage
+		if err := rows.Scan(append(messageFields(&p.Message), &p.PinnedBy, &p.PinnedAt)...); err != nil {
+			return nil, fmt.Errorf("scan pin: %w", err)
+		}
+		pins = append(pins, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pins: %w", err)
+	}
+
+	return pins, nil
+}
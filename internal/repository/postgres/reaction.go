@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lalith-99/echostream/internal/models"
+)
+
+type ReactionStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewReactionStore returns a Postgres-backed reaction store.
+func NewReactionStore(pool *pgxpool.Pool) *ReactionStore {
+	return &ReactionStore{pool: pool}
+}
+
+func (s *ReactionStore) Add(ctx context.Context, messageID int64, userID uuid.UUID, emoji string) (bool, error) {
+	// ON CONFLICT DO NOTHING makes this idempotent; RowsAffected tells us
+	// whether it was a new reaction (so we only broadcast real changes).
+	query := `
+		INSERT INTO message_reactions (message_id, user_id, emoji, created_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (message_id, user_id, emoji) DO NOTHING`
+
+	tag, err := s.pool.Exec(ctx, query, messageID, userID, emoji)
+	if err != nil {
+		return false, fmt.Errorf("add reaction: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *ReactionStore) Remove(ctx context.Context, messageID int64, userID uuid.UUID, emoji string) (bool, error) {
+	query := `
+		DELETE FROM message_reactions
+		WHERE message_id = $1 AND user_id = $2 AND emoji = $3`
+
+	tag, err := s.pool.Exec(ctx, query, messageID, userID, emoji)
+	if err != nil {
+		return false, fmt.Errorf("remove reaction: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *ReactionStore) Summaries(ctx context.Context, messageIDs []int64, viewerID uuid.UUID) (map[int64][]models.ReactionSummary, error) {
+	result := make(map[int64][]models.ReactionSummary)
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	// Emoji are ordered by first use so the UI doesn't reshuffle as counts change.
+	query := `
+		SELECT message_id, emoji, count(*), bool_or(user_id = $2)
+		FROM message_reactions
+		WHERE message_id = ANY($1)
+		GROUP BY message_id, emoji
+		ORDER BY message_id, min(created_at)`
+
+	rows, err := s.pool.Query(ctx, query, messageIDs, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("summarize reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID int64
+		var r models.ReactionSummary
+		if err := rows.Scan(&messageID, &r.Emoji, &r.Count, &r.Reacted); err != nil {
+			return nil, fmt.Errorf("scan reaction summary: %w", err)
+		}
+		result[messageID] = append(result[messageID], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate reaction summaries: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lalith-99/echostream/internal/models"
+)
+
+// scheduledColumns is the SELECT/RETURNING list that scheduledFields expects.
+const scheduledColumns = `id, tenant_id, channel_id, sender_id, body, parent_id, attachment_ids,
+	client_msg_id, send_at, status, last_error, message_id, created_at, updated_at`
+
+// unclaimed matches rows no scheduler currently holds a delivery lease on.
+const unclaimed = `(claimed_until IS NULL OR claimed_until < now())`
+
+type ScheduledMessageStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewScheduledMessageStore returns a Postgres-backed scheduled message store.
+func NewScheduledMessageStore(pool *pgxpool.Pool) *ScheduledMessageStore {
+	return &ScheduledMessageStore{pool: pool}
+}
+
+// scheduledFields returns scan targets in scheduledColumns order.
+func scheduledFields(m *models.ScheduledMessage) []any {
+	return []any{
+		&m.ID,
+		&m.TenantID,
+		&m.ChannelID,
+		&m.SenderID,
+		&m.Body,
+		&m.ParentID,
+		&m.AttachmentIDs,
+		&m.ClientMsgID,
+		&m.SendAt,
+		&m.Status,
+		&m.Error,
+		&m.MessageID,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	}
+}
+
+func (s *ScheduledMessageStore) Create(ctx context.Context, m *models.ScheduledMessage) (*models.ScheduledMessage, bool, error) {
+	attachmentIDs := m.AttachmentIDs
+	if attachmentIDs == nil {
+		attachmentIDs = []uuid.UUID{}
+	}
+	query := `
+		INSERT INTO scheduled_messages (tenant_id, channel_id, sender_id, body, parent_id, attachment_ids, client_msg_id, send_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (sender_id, channel_id, client_msg_id) WHERE client_msg_id IS NOT NULL DO NOTHING
+		RETURNING ` + scheduledColumns
+
+	var out models.ScheduledMessage
+	err := s.pool.QueryRow(ctx, query,
+		m.TenantID, m.ChannelID, m.SenderID, m.Body, m.ParentID, attachmentIDs, m.ClientMsgID, m.SendAt,
+	).Scan(scheduledFields(&out)...)
+	if err == nil {
+		return &out, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("insert scheduled message: %w", err)
+	}
+
+	err = s.pool.QueryRow(ctx,
+		`SELECT `+scheduledColumns+` FROM scheduled_messages
+		 WHERE tenant_id = $1 AND channel_id = $2 AND sender_id = $3 AND client_msg_id = $4`,
+		m.TenantID, m.ChannelID, m.SenderID, m.ClientMsgID,
+	).Scan(scheduledFields(&out)...)
+	if err != nil {
+		return nil, false, fmt.Errorf("get scheduled message by client_msg_id: %w", err)
+	}
+	return &out, false, nil
+}
+
+func (s *ScheduledMessageStore) GetByID(ctx context.Context, tenantID uuid.UUID, senderID uuid.UUID, id uuid.UUID) (*models.ScheduledMessage, error) {
+	query := `
+		SELECT ` + scheduledColumns + `
+		FROM scheduled_messages
+		WHERE id = $1 AND tenant_id = $2 AND sender_id = $3`
+
+	var m models.ScheduledMessage
+	err := s.pool.QueryRow(ctx, query, id, tenantID, senderID).Scan(scheduledFields(&m)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get scheduled message: %w", err)
+	}
+	return &m, nil
+}
+
+func (s *ScheduledMessageStore) ListBySender(ctx context.Context, tenantID uuid.UUID, senderID uuid.UUID) ([]models.ScheduledMessage, error) {
+	query := `
+		SELECT ` + scheduledColumns + `
+		FROM scheduled_messages
+		WHERE tenant_id = $1 AND sender_id = $2 AND status IN ('pending', 'failed')
+		ORDER BY send_at, id`
+
+	rows, err := s.pool.Query(ctx, query, tenantID, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled messages: %w", err)
+	}
+	return collectScheduled(rows)
+}
+
+func (s *ScheduledMessageStore) Update(ctx context.Context, tenantID uuid.UUID, senderID uuid.UUID, id uuid.UUID, body string, sendAt time.Time) (*models.ScheduledMessage, error) {
+	query := `
+		UPDATE scheduled_messages
+		SET body = $4, send_at = $5, updated_at = now()
+		WHERE id = $1 AND tenant_id = $2 AND sender_id = $3
+		  AND status = 'pending' AND ` + unclaimed + `
+		RETURNING ` + scheduledColumns
+
+	var m models.ScheduledMessage
+	err := s.pool.QueryRow(ctx, query, id, tenantID, senderID, body, sendAt).Scan(scheduledFields(&m)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("update scheduled message: %w", err)
+	}
+	return &m, nil
+}
+
+func (s *ScheduledMessageStore) Delete(ctx context.Context, tenantID uuid.UUID, senderID uuid.UUID, id uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM scheduled_messages
+		WHERE id = $1 AND tenant_id = $2 AND sender_id = $3
+		  AND status IN ('pending', 'failed') AND ` + unclaimed
+
+	tag, err := s.pool.Exec(ctx, query, id, tenantID, senderID)
+	if err != nil {
+		return false, fmt.Errorf("delete scheduled message: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ClaimDue takes a lease on due rows in one statement. SKIP LOCKED lets
+// concurrent schedulers claim disjoint batches instead of queueing on
+// each other's row locks; the lease keeps a row claimed after the
+// statement's transaction ends.
+func (s *ScheduledMessageStore) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.ScheduledMessage, error) {
+	query := `
+		UPDATE scheduled_messages
+		SET claimed_until = now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM scheduled_messages
+			WHERE status = 'pending' AND send_at <= now() AND ` + unclaimed + `
+			ORDER BY send_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduledColumns
+
+	rows, err := s.pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim scheduled messages: %w", err)
+	}
+	return collectScheduled(rows)
+}
+
+func (s *ScheduledMessageStore) MarkSent(ctx context.Context, id uuid.UUID, messageID int64) error {
+	query := `
+		UPDATE scheduled_messages
+		SET status = 'sent', message_id = $2, claimed_until = NULL, updated_at = now()
+		WHERE id = $1`
+
+	if _, err := s.pool.Exec(ctx, query, id, messageID); err != nil {
+		return fmt.Errorf("mark scheduled message sent: %w", err)
+	}
+	return nil
+}
+
+func (s *ScheduledMessageStore) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `
+		UPDATE scheduled_messages
+		SET status = 'failed', last_error = $2, claimed_until = NULL, updated_at = now()
+		WHERE id = $1`
+
+	if _, err := s.pool.Exec(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("mark scheduled message failed: %w", err)
+	}
+	return nil
+}
+
+func collectScheduled(rows pgx.Rows) ([]models.ScheduledMessage, error) {
+	defer rows.Close()
+
+	out := make([]models.ScheduledMessage, 0)
+	for rows.Next() {
+		var m models.ScheduledMessage
+		if err := rows.Scan(scheduledFields(&m)...); err != nil {
+			return nil, fmt.Errorf("scan scheduled message: %w", err)
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate scheduled messages: %w", err)
+	}
+	return out, nil
+}
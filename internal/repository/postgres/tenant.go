@@ -2,8 +2,11 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lalith-99/echostream/internal/models"
 )
@@ -34,3 +37,20 @@ func (s *TenantStore) Create(ctx context.Context, name string) (*models.Tenant,
 	}
 	return &t, nil
 }
+
+func (s *TenantStore) MaxUploadBytes(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	query := `
+		SELECT COALESCE(max_upload_bytes, 0)
+		FROM tenants
+		WHERE id = $1`
+
+	var limit int64
+	err := s.pool.QueryRow(ctx, query, tenantID).Scan(&limit)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("get tenant upload limit: %w", err)
+	}
+	return limit, nil
+}
@@ -90,3 +90,16 @@ func (s *UserStore) GetByEmail(ctx context.Context, email string) (*models.User,
 	}
 	return &u, nil
 }
+
+func (s *UserStore) CountInTenant(ctx context.Context, tenantID uuid.UUID, userIDs []uuid.UUID) (int, error) {
+	query := `
+		SELECT count(*)
+		FROM users
+		WHERE tenant_id = $1 AND id = ANY($2)`
+
+	var n int
+	if err := s.pool.QueryRow(ctx, query, tenantID, userIDs).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count users: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+)
+
+// resolveAttachments loads the files for a send, in the order given.
+// Every file must be an upload by senderID to channelID; anything else
+// (unknown, another channel, someone else's upload) is rejected the same
+// way so the error doesn't reveal which files exist.
+func (s *MessageService) resolveAttachments(ctx context.Context, tenantID, channelID, senderID uuid.UUID, ids []uuid.UUID) ([]models.File, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if s.files == nil {
+		return nil, ErrInvalidAttachment
+	}
+
+	found, err := s.files.GetByIDs(ctx, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.File, len(found))
+	for _, f := range found {
+		byID[f.ID] = f
+	}
+
+	files := make([]models.File, 0, len(ids))
+	seen := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		f, ok := byID[id]
+		if !ok || f.ChannelID != channelID || f.UploaderID != senderID {
+			return nil, ErrInvalidAttachment
+		}
+		if _, dup := seen[id]; dup {
+			return nil, ErrInvalidAttachment
+		}
+		seen[id] = struct{}{}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// attachFiles fills in Attachments for a page of messages with one query.
+func (s *MessageService) attachFiles(ctx context.Context, messages []models.Message) error {
+	if s.files == nil || len(messages) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	attachments, err := s.files.ForMessages(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		messages[i].Attachments = attachments[messages[i].ID]
+	}
+	return nil
+}
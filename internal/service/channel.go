@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	maxChannelName           = 80  // bytes
+	maxChannelTopic          = 250 // bytes
+	maxChannelPurpose        = 250 // bytes
+	maxNameSuggestions       = 20  // -2 .. -21 before giving up on a suggestion
+	channelCreatedEventType  = "channel_created"
+	channelUpdatedEventType  = "channel_updated"
+	channelArchivedEventType = "channel_archived"
+	channelHiddenEventType   = "channel_hidden"
+)
+
+var (
+	ErrChannelNotFound    = errors.New("channel not found")
+	ErrNotChannelAdmin    = errors.New("only channel admins can change channel settings")
+	ErrInvalidChannelName = errors.New("channel name must be 1-80 lowercase letters, digits, '-' or '_'")
+	ErrChannelNameTaken   = repository.ErrChannelNameTaken
+	ErrTopicTooLong       = errors.New("topic exceeds maximum length")
+	ErrPurposeTooLong     = errors.New("purpose exceeds maximum length")
+	ErrConvertDM          = errors.New("direct messages can't be converted")
+)
+
+// SubscriptionEvictor drops live websocket subscriptions to a channel for
+// users who may no longer read it, on every node. Implemented by
+// redis.Client, which fans the request out to each node's hub.
+type SubscriptionEvictor interface {
+	// EvictSubscribers drops the given users' subscriptions.
+	EvictSubscribers(ctx context.Context, channelID uuid.UUID, userIDs []uuid.UUID) error
+	// RetainSubscribers drops everyone's subscriptions except the given users'.
+	RetainSubscribers(ctx context.Context, channelID uuid.UUID, userIDs []uuid.UUID) error
+}
+
+// ChannelNameTakenError is returned when a channel name is already in use
+// in the tenant. It matches ErrChannelNameTaken with errors.Is and carries
+// a free alternative when one was found.
+type ChannelNameTakenError struct {
+	Name       string
+	Suggestion string // empty if no nearby name is free
+}
+
+func (e *ChannelNameTakenError) Error() string {
+	return fmt.Sprintf("channel name %q is already taken", e.Name)
+}
+
+func (e *ChannelNameTakenError) Is(target error) bool {
+	return target == ErrChannelNameTaken
+}
+
+// NormalizeChannelName turns user input into the stored form of a channel
+// name: a leading '#' and surrounding space are dropped, letters are
+// lowercased and runs of whitespace become '-'. What's left may only
+// contain a-z, 0-9, '-' and '_'.
+func NormalizeChannelName(name string) (string, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "#")
+	name = strings.Join(strings.Fields(strings.ToLower(name)), "-")
+	if name == "" || len(name) > maxChannelName {
+		return "", ErrInvalidChannelName
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return "", ErrInvalidChannelName
+		}
+	}
+	return name, nil
+}
+
+// ChannelService owns channel naming and a channel's own settings (name,
+// topic, purpose, archive state), and tells subscribers about changes.
+type ChannelService struct {
+	channels   repository.ChannelRepository
+	membership repository.MembershipRepository
+	perms      permissions
+	events     *Events
+	evictor    SubscriptionEvictor // nil = subscriptions are only checked at subscribe time
+	logger     *zap.Logger
+}
+
+// NewChannelService builds a ChannelService.
+func NewChannelService(
+	channels repository.ChannelRepository,
+	membership repository.MembershipRepository,
+	publisher EventPublisher,
+	evictor SubscriptionEvictor,
+	logger *zap.Logger,
+) *ChannelService {
+	return &ChannelService{
+		channels:   channels,
+		membership: membership,
+		perms:      permissions{membership: membership},
+		events:     NewEvents(publisher, logger),
+		evictor:    evictor,
+		logger:     logger,
+	}
+}
+
+// Create makes a new channel with a normalized, tenant-unique name.
+func (s *ChannelService) Create(ctx context.Context, p repository.CreateChannelParams) (*models.Channel, error) {
+	name, err := NormalizeChannelName(p.Name)
+	if err != nil {
+		return nil, err
+	}
+	p.Name = name
+
+	ch, err := s.channels.Create(ctx, p)
+	if errors.Is(err, repository.ErrChannelNameTaken) {
+		return nil, s.nameTaken(ctx, p.TenantID, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tenantVisible(ch) {
+		s.publishTenant(ctx, channelCreatedEventType, ch, p.CreatedBy)
+	}
+	return ch, nil
+}
+
+// GetByName looks a channel up by name for viewerID, normalizing it first
+// so "#General" finds "general". Returns ErrChannelNotFound if there is no
+// such channel, or it's private and the viewer isn't a member.
+func (s *ChannelService) GetByName(ctx context.Context, tenantID, viewerID uuid.UUID, name string) (*models.Channel, error) {
+	name, err := NormalizeChannelName(name)
+	if err != nil {
+		return nil, ErrChannelNotFound
+	}
+	ch, err := s.channels.GetByName(ctx, tenantID, name)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, ErrChannelNotFound
+	}
+	if ch.IsPrivate {
+		member, err := s.membership.IsMember(ctx, ch.ID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !member {
+			return nil, ErrChannelNotFound
+		}
+	}
+	return ch, nil
+}
+
+// Update applies u to the channel on behalf of userID, who must be a
+// channel admin.
+//
+// An archived channel is frozen: the only change it accepts is being
+// unarchived. A request that changes nothing returns the channel as-is
+// without publishing. Changes to public channels are also announced to
+// the whole tenant, archiving as channel_archived.
+func (s *ChannelService) Update(ctx context.Context, tenantID, channelID, userID uuid.UUID, u repository.ChannelUpdate) (*models.Channel, error) {
+	if u.Name != nil {
+		name, err := NormalizeChannelName(*u.Name)
+		if err != nil {
+			return nil, err
+		}
+		u.Name = &name
+	}
+	if u.Topic != nil && len(*u.Topic) > maxChannelTopic {
+		return nil, ErrTopicTooLong
+	}
+	if u.Purpose != nil && len(*u.Purpose) > maxChannelPurpose {
+		return nil, ErrPurposeTooLong
+	}
+
+	ch, err := s.channels.GetByID(ctx, tenantID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	if _, err := s.perms.require(ctx, channelID, userID, ActionManageChannel, ErrNotChannelAdmin); err != nil {
+		return nil, err
+	}
+
+	if ch.IsArchived() {
+		unarchive := u.Archived != nil && !*u.Archived
+		if !unarchive && (u.Name != nil || u.Topic != nil || u.Purpose != nil) {
+			return nil, ErrChannelArchived
+		}
+	}
+	if !channelChanged(ch, u) {
+		return ch, nil
+	}
+
+	updated, err := s.channels.Update(ctx, tenantID, channelID, u)
+	if errors.Is(err, repository.ErrChannelNameTaken) {
+		return nil, s.nameTaken(ctx, tenantID, *u.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	s.publishUpdated(ctx, updated, userID)
+	if tenantVisible(updated) {
+		eventType := channelUpdatedEventType
+		if updated.IsArchived() && !ch.IsArchived() {
+			eventType = channelArchivedEventType
+		}
+		s.publishTenant(ctx, eventType, updated, userID)
+	}
+	return updated, nil
+}
+
+// Convert makes a channel private or public on behalf of userID, who must
+// be a channel admin. The change is audited by the repository.
+//
+// Going private evicts live subscribers who aren't members, so they stop
+// receiving messages right away rather than at reconnect. The tenant gets
+// a channel_hidden event carrying only the channel ID, so channel lists
+// can drop it without learning its name or topic. Going public sends the
+// tenant the full channel, so lists can add it.
+func (s *ChannelService) Convert(ctx context.Context, tenantID, channelID, userID uuid.UUID, private bool) (*models.Channel, error) {
+	ch, err := s.channels.GetByID(ctx, tenantID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, ErrChannelNotFound
+	}
+	if ch.IsDM() {
+		return nil, ErrConvertDM
+	}
+	if _, err := s.perms.require(ctx, channelID, userID, ActionManageChannel, ErrNotChannelAdmin); err != nil {
+		return nil, err
+	}
+	if ch.IsArchived() {
+		return nil, ErrChannelArchived
+	}
+
+	updated, changed, err := s.channels.SetPrivate(ctx, tenantID, channelID, userID, private)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrChannelNotFound
+	}
+	if !changed {
+		return updated, nil
+	}
+
+	if private && s.evictor != nil {
+		// The conversion is committed either way; if eviction fails, stale
+		// subscribers go at reconnect.
+		members, err := s.membership.ListMemberIDs(ctx, channelID)
+		if err == nil {
+			err = s.evictor.RetainSubscribers(ctx, channelID, members)
+		}
+		if err != nil {
+			s.logger.Error("failed to evict non-member subscribers", zap.Error(err))
+		}
+	}
+
+	s.publishUpdated(ctx, updated, userID)
+	if tenantVisible(updated) {
+		s.publishTenant(ctx, channelUpdatedEventType, updated, userID)
+	} else {
+		s.events.PublishToTenant(ctx, tenantID, websocket.OutboundEvent{
+			Type:      channelHiddenEventType,
+			ChannelID: channelID.String(),
+		})
+	}
+	return updated, nil
+}
+
+// publishUpdated sends channel_updated to the channel's subscribers.
+func (s *ChannelService) publishUpdated(ctx context.Context, ch *models.Channel, actorID uuid.UUID) {
+	s.events.PublishToChannel(ctx, ch.ID, websocket.OutboundEvent{
+		Type:      channelUpdatedEventType,
+		ChannelID: ch.ID.String(),
+		Channel:   ch,
+		UserID:    actorID.String(),
+	})
+}
+
+// publishTenant sends a channel lifecycle event to everyone in the
+// channel's tenant.
+func (s *ChannelService) publishTenant(ctx context.Context, eventType string, ch *models.Channel, actorID uuid.UUID) {
+	s.events.PublishToTenant(ctx, ch.TenantID, websocket.OutboundEvent{
+		Type:      eventType,
+		ChannelID: ch.ID.String(),
+		Channel:   ch,
+		UserID:    actorID.String(),
+	})
+}
+
+// tenantVisible reports whether everyone in the tenant may see ch.
+func tenantVisible(ch *models.Channel) bool {
+	return !ch.IsPrivate && !ch.IsDM()
+}
+
+// channelChanged reports whether applying u would change ch.
+func channelChanged(ch *models.Channel, u repository.ChannelUpdate) bool {
+	return (u.Name != nil && *u.Name != ch.Name) ||
+		(u.Topic != nil && *u.Topic != ch.Topic) ||
+		(u.Purpose != nil && *u.Purpose != ch.Purpose) ||
+		(u.Archived != nil && *u.Archived != ch.IsArchived())
+}
+
+// nameTaken builds a ChannelNameTakenError, suggesting the first free
+// name-N. The suggestion is a hint, not a reservation: someone else can
+// still take it first. Lookup failures just leave it out.
+func (s *ChannelService) nameTaken(ctx context.Context, tenantID uuid.UUID, name string) error {
+	taken := &ChannelNameTakenError{Name: name}
+	for n := 2; n < 2+maxNameSuggestions; n++ {
+		suffix := fmt.Sprintf("-%d", n)
+		candidate := name
+		if len(candidate)+len(suffix) > maxChannelName {
+			candidate = candidate[:maxChannelName-len(suffix)]
+		}
+		candidate += suffix
+
+		existing, err := s.channels.GetByName(ctx, tenantID, candidate)
+		if err != nil {
+			s.logger.Warn("failed to look up channel name suggestion", zap.Error(err))
+			break
+		}
+		if existing == nil {
+			taken.Suggestion = candidate
+			break
+		}
+	}
+	return taken
+}
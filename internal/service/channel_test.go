@@ -0,0 +1,30 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeChannelName(t *testing.T) {
+	cases := map[string]string{
+		"general":          "general",
+		"#General":         "general",
+		"  My   Team  ":    "my-team",
+		"release_2024-q3":  "release_2024-q3",
+		"Tab\tand\nbreaks": "tab-and-breaks",
+	}
+	for in, want := range cases {
+		got, err := NormalizeChannelName(in)
+		if err != nil || got != want {
+			t.Errorf("%q: got %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestNormalizeChannelName_Invalid(t *testing.T) {
+	for _, in := range []string{"", "   ", "#", "hello!", "café", "a.b", strings.Repeat("x", 81)} {
+		if _, err := NormalizeChannelName(in); err != ErrInvalidChannelName {
+			t.Errorf("%q: expected ErrInvalidChannelName, got %v", in, err)
+		}
+	}
+}
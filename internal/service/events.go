@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/websocket"
+	"go.uber.org/zap"
+)
+
+// Redis topics the hubs listen on. A node subscribes to a channel topic
+// while it has subscribers for that channel, and to a user or tenant topic
+// while that user or tenant has a connection open to it.
+const (
+	channelTopicPrefix = "ch:"
+	userTopicPrefix    = "user:"
+	tenantTopicPrefix  = "tenant:"
+)
+
+// EventPublisher pushes events to a pub/sub system (e.g., Redis).
+type EventPublisher interface {
+	Publish(ctx context.Context, channel string, payload []byte) error
+}
+
+// Events sends websocket events to every node via Redis. Delivery is
+// best-effort: the write behind an event is already saved, so failures
+// are logged rather than returned. New messages go through OutboxRelay
+// instead when one is configured.
+type Events struct {
+	publisher EventPublisher // nil = events are dropped
+	logger    *zap.Logger
+}
+
+// NewEvents builds an Events on top of publisher.
+func NewEvents(publisher EventPublisher, logger *zap.Logger) *Events {
+	return &Events{publisher: publisher, logger: logger}
+}
+
+// PublishToChannel sends an event to everyone subscribed to the channel,
+// on any node.
+func (e *Events) PublishToChannel(ctx context.Context, channelID uuid.UUID, event websocket.OutboundEvent) {
+	e.publish(ctx, channelTopicPrefix+channelID.String(), event)
+}
+
+// PublishToUser sends an event to every connection of one user, on any
+// node, regardless of their channel subscriptions.
+func (e *Events) PublishToUser(ctx context.Context, userID uuid.UUID, event websocket.OutboundEvent) {
+	e.publish(ctx, userTopicPrefix+userID.String(), event)
+}
+
+// PublishToTenant sends an event to every connection in the tenant, on any
+// node. Only use it for things every user in the tenant may see.
+func (e *Events) PublishToTenant(ctx context.Context, tenantID uuid.UUID, event websocket.OutboundEvent) {
+	e.publish(ctx, tenantTopicPrefix+tenantID.String(), event)
+}
+
+// topicEvent is an event bound for one topic, for code that builds events
+// before deciding whether to publish them now or store them in the outbox.
+type topicEvent struct {
+	topic string
+	event websocket.OutboundEvent
+}
+
+func channelEvent(channelID uuid.UUID, event websocket.OutboundEvent) topicEvent {
+	return topicEvent{topic: channelTopicPrefix + channelID.String(), event: event}
+}
+
+func userEvent(userID uuid.UUID, event websocket.OutboundEvent) topicEvent {
+	return topicEvent{topic: userTopicPrefix + userID.String(), event: event}
+}
+
+// publishAll publishes events in order.
+func (e *Events) publishAll(ctx context.Context, events []topicEvent) {
+	for _, te := range events {
+		e.publish(ctx, te.topic, te.event)
+	}
+}
+
+// outboxEvents serializes events for the outbox.
+func outboxEvents(events []topicEvent) ([]models.OutboxEvent, error) {
+	out := make([]models.OutboxEvent, len(events))
+	for i, te := range events {
+		data, err := json.Marshal(te.event)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = models.OutboxEvent{Topic: te.topic, Payload: data}
+	}
+	return out, nil
+}
+
+func (e *Events) publish(ctx context.Context, topic string, event websocket.OutboundEvent) {
+	if e.publisher == nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		e.logger.Error("failed to marshal event", zap.String("type", event.Type), zap.Error(err))
+		return
+	}
+	if err := e.publisher.Publish(ctx, topic, data); err != nil {
+		e.logger.Error("publish failed (write is saved, delivery is best-effort)",
+			zap.Error(err),
+			zap.String("type", event.Type),
+			zap.String("topic", topic),
+		)
+	}
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/media"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	maxFileName = 255 // bytes
+	sniffLen    = 512 // bytes http.DetectContentType looks at
+
+	maxImageJobs    = 2 // concurrent decodes; each can hold a large bitmap
+	imageJobTimeout = 2 * time.Minute
+)
+
+// thumbnailSizes are the longest-edge sizes generated for images, in
+// ascending order. Sizes at or above the original's longest edge are
+// skipped; clients use the original instead.
+var thumbnailSizes = []int{64, 360, 720}
+
+// imageContentTypes are the sniffed types the stdlib can decode.
+var imageContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+var (
+	ErrFileNotFound    = errors.New("file not found")
+	ErrFileTooLarge    = errors.New("file exceeds the upload size limit")
+	ErrEmptyFile       = errors.New("file is empty")
+	ErrInvalidFileName = errors.New("file name must be 1-255 bytes")
+)
+
+// FileService owns uploads and downloads. Bytes go to the BlobStore;
+// metadata and access scoping live in Postgres.
+type FileService struct {
+	files           repository.FileRepository
+	tenants         repository.TenantRepository
+	membership      repository.MembershipRepository
+	blobs           storage.BlobStore
+	defaultMaxBytes int64
+	logger          *zap.Logger
+
+	imageSem  chan struct{}  // limits concurrent image processing
+	imageJobs sync.WaitGroup // in-flight processing, for Wait
+}
+
+// NewFileService builds a FileService. defaultMaxBytes applies to tenants
+// without their own max_upload_bytes.
+func NewFileService(
+	files repository.FileRepository,
+	tenants repository.TenantRepository,
+	membership repository.MembershipRepository,
+	blobs storage.BlobStore,
+	defaultMaxBytes int64,
+	logger *zap.Logger,
+) *FileService {
+	return &FileService{
+		files:           files,
+		tenants:         tenants,
+		membership:      membership,
+		blobs:           blobs,
+		defaultMaxBytes: defaultMaxBytes,
+		logger:          logger,
+		imageSem:        make(chan struct{}, maxImageJobs),
+	}
+}
+
+// UploadLimit returns the tenant's maximum upload size in bytes.
+func (s *FileService) UploadLimit(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	limit, err := s.tenants.MaxUploadBytes(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return s.defaultMaxBytes, nil
+	}
+	return limit, nil
+}
+
+// Upload stores a file in a channel.
+//
+// Rules: the uploader must be a channel member, the file must be
+// non-empty and within the tenant's size limit. The content type is
+// sniffed from the bytes; whatever the client claimed is ignored.
+//
+// Images get thumbnails, dimensions and a blurhash in the background;
+// the returned file doesn't have them yet.
+func (s *FileService) Upload(ctx context.Context, tenantID, channelID, uploaderID uuid.UUID, name string, r io.Reader) (*models.File, error) {
+	name, err := cleanFileName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireMember(ctx, channelID, uploaderID); err != nil {
+		return nil, err
+	}
+	limit, err := s.UploadLimit(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrEmptyFile
+	}
+	head = head[:n]
+
+	f := &models.File{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		ChannelID:   channelID,
+		UploaderID:  uploaderID,
+		Name:        name,
+		ContentType: http.DetectContentType(head),
+	}
+	f.StorageKey = tenantID.String() + "/" + f.ID.String()
+
+	// Read one byte past the limit so an oversized upload is detectable.
+	body := &io.LimitedReader{R: io.MultiReader(bytes.NewReader(head), r), N: limit + 1}
+	if err := s.blobs.Put(ctx, f.StorageKey, body); err != nil {
+		return nil, err
+	}
+	f.SizeBytes = limit + 1 - body.N
+	if f.SizeBytes > limit {
+		s.deleteBlob(ctx, f.StorageKey)
+		return nil, ErrFileTooLarge
+	}
+
+	created, err := s.files.Create(ctx, f)
+	if err != nil {
+		s.deleteBlob(ctx, f.StorageKey)
+		return nil, err
+	}
+	if imageContentTypes[created.ContentType] {
+		s.processImageAsync(*created)
+	}
+	return created, nil
+}
+
+// Open returns a file's metadata and contents for download. The caller
+// must close the reader. Only members of the file's channel may read it.
+func (s *FileService) Open(ctx context.Context, tenantID, userID, fileID uuid.UUID) (*models.File, io.ReadCloser, error) {
+	f, err := s.getReadable(ctx, tenantID, userID, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.openBlob(ctx, f.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, rc, nil
+}
+
+// OpenThumbnail is Open for one of an image's generated thumbnails.
+// Thumbnails are encoded as media.ThumbnailContentType(f.ContentType).
+func (s *FileService) OpenThumbnail(ctx context.Context, tenantID, userID, fileID uuid.UUID, size int) (*models.File, io.ReadCloser, error) {
+	f, err := s.getReadable(ctx, tenantID, userID, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !slices.Contains(f.Thumbnails, size) {
+		return nil, nil, ErrFileNotFound
+	}
+	rc, err := s.openBlob(ctx, thumbnailKey(f.StorageKey, size))
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, rc, nil
+}
+
+// Wait blocks until background image processing has finished.
+func (s *FileService) Wait() {
+	s.imageJobs.Wait()
+}
+
+// getReadable loads a file's metadata if userID may read it: the file
+// must be in the tenant and the user a member of its channel.
+func (s *FileService) getReadable(ctx context.Context, tenantID, userID, fileID uuid.UUID) (*models.File, error) {
+	f, err := s.files.GetByID(ctx, tenantID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, ErrFileNotFound
+	}
+	if err := s.requireMember(ctx, f.ChannelID, userID); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *FileService) openBlob(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrFileNotFound
+		}
+		return nil, err
+	}
+	return rc, nil
+}
+
+// processImageAsync runs processImage off the request path. Failures
+// only mean the file has no previews, so they are logged, not surfaced.
+func (s *FileService) processImageAsync(f models.File) {
+	s.imageJobs.Add(1)
+	go func() {
+		defer s.imageJobs.Done()
+		s.imageSem <- struct{}{}
+		defer func() { <-s.imageSem }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), imageJobTimeout)
+		defer cancel()
+		if err := s.processImage(ctx, f); err != nil {
+			s.logger.Warn("image processing failed",
+				zap.String("file_id", f.ID.String()),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// processImage decodes an uploaded image, stores its thumbnails and
+// records its dimensions and blurhash.
+func (s *FileService) processImage(ctx context.Context, f models.File) error {
+	rc, err := s.blobs.Get(ctx, f.StorageKey)
+	if err != nil {
+		return err
+	}
+	img, err := media.Decode(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	// One full-size conversion, shared by every thumbnail.
+	src := media.ToRGBA(img)
+	smallest := src // the blurhash only needs a few pixels
+	stored := make([]int, 0, len(thumbnailSizes))
+	for _, size := range thumbnailSizes {
+		if size >= max(width, height) {
+			break
+		}
+		thumb := media.Resize(src, size)
+		if len(stored) == 0 {
+			smallest = thumb
+		}
+		var buf bytes.Buffer
+		if err := media.Encode(&buf, thumb, f.ContentType); err != nil {
+			return fmt.Errorf("encode %dpx thumbnail: %w", size, err)
+		}
+		if err := s.blobs.Put(ctx, thumbnailKey(f.StorageKey, size), &buf); err != nil {
+			return err
+		}
+		stored = append(stored, size)
+	}
+
+	return s.files.SetImageMetadata(ctx, f.ID, width, height, media.Blurhash(smallest), stored)
+}
+
+// thumbnailKey places thumbnails next to the original in the blob store.
+func thumbnailKey(storageKey string, size int) string {
+	return fmt.Sprintf("%s_%d", storageKey, size)
+}
+
+func (s *FileService) requireMember(ctx context.Context, channelID, userID uuid.UUID) error {
+	ok, err := s.membership.IsMember(ctx, channelID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotMember
+	}
+	return nil
+}
+
+// deleteBlob cleans up after a failed upload. A leftover blob only
+// wastes space, so failures are logged, not returned.
+func (s *FileService) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		s.logger.Error("failed to delete orphaned blob", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// cleanFileName keeps only the base name and drops control characters,
+// so the stored name is safe to echo in a Content-Disposition header.
+func cleanFileName(name string) (string, error) {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" || len(name) > maxFileName {
+		return "", ErrInvalidFileName
+	}
+	return name, nil
+}
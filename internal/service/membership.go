@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	memberJoinedEventType       = "member_joined"
+	memberLeftEventType         = "member_left"
+	memberInvitedEventType      = "member_invited"
+	addedToChannelEventType     = "added_to_channel"
+	removedFromChannelEventType = "removed_from_channel"
+)
+
+var (
+	ErrInvalidRole        = errors.New("role must be 'member', 'admin' or 'owner'")
+	ErrInviteRequired     = errors.New("private channel — invite required")
+	ErrInviteNotAllowed   = errors.New("only channel admins can invite to a private channel")
+	ErrDMMembership       = errors.New("a direct message's members can't be changed")
+	ErrTargetNotMember    = errors.New("user is not a member of this channel")
+	ErrCannotManageMember = errors.New("you can't change this member's role or membership")
+	ErrOwnerMustTransfer  = errors.New("the owner must transfer ownership before leaving")
+)
+
+// MembershipService owns who may join, leave, invite, change roles and
+// remove members. Role checks go through permissions.
+type MembershipService struct {
+	membership repository.MembershipRepository
+	channels   repository.ChannelRepository
+	perms      permissions
+	events     *Events
+	evictor    SubscriptionEvictor // nil = subscriptions are only checked at subscribe time
+	logger     *zap.Logger
+}
+
+// NewMembershipService builds a MembershipService.
+func NewMembershipService(
+	membership repository.MembershipRepository,
+	channels repository.ChannelRepository,
+	publisher EventPublisher,
+	evictor SubscriptionEvictor,
+	logger *zap.Logger,
+) *MembershipService {
+	return &MembershipService{
+		membership: membership,
+		channels:   channels,
+		perms:      permissions{membership: membership},
+		events:     NewEvents(publisher, logger),
+		evictor:    evictor,
+		logger:     logger,
+	}
+}
+
+// Join adds userID to a public channel as a member. A private channel can
+// only be "joined" by someone already invited, which is then a no-op, as is
+// joining a channel you're already in. Nobody can join with a higher role;
+// roles are granted with SetRole.
+func (s *MembershipService) Join(ctx context.Context, tenantID, channelID, userID uuid.UUID) error {
+	ch, err := s.channel(ctx, tenantID, channelID)
+	if err != nil {
+		return err
+	}
+	if ch.IsArchived() {
+		return ErrChannelArchived
+	}
+	already, err := s.membership.IsMember(ctx, channelID, userID)
+	if err != nil {
+		return err
+	}
+	if already {
+		return nil
+	}
+	if ch.IsPrivate {
+		return ErrInviteRequired
+	}
+
+	added, err := s.membership.AddMember(ctx, channelID, userID, RoleMember)
+	if err != nil {
+		return err
+	}
+	if added { // a concurrent join may have got there first
+		s.publishMember(ctx, memberJoinedEventType, channelID, userID, userID)
+	}
+	return nil
+}
+
+// Leave removes userID from the channel, or returns ErrNotMember. The
+// owner has to hand ownership to someone else first, unless nobody else
+// is left.
+func (s *MembershipService) Leave(ctx context.Context, tenantID, channelID, userID uuid.UUID) error {
+	if _, err := s.channel(ctx, tenantID, channelID); err != nil {
+		return err
+	}
+
+	role, err := s.membership.GetRole(ctx, channelID, userID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrNotMember
+	}
+	if Can(role, ActionTransferOwnership) {
+		members, err := s.membership.ListMemberIDs(ctx, channelID)
+		if err != nil {
+			return err
+		}
+		if len(members) > 1 {
+			return ErrOwnerMustTransfer
+		}
+	}
+	left, err := s.membership.RemoveMember(ctx, channelID, userID)
+	if err != nil || !left {
+		return err
+	}
+	s.removed(ctx, channelID, userID, userID)
+	s.publishMember(ctx, memberLeftEventType, channelID, userID, userID)
+	return nil
+}
+
+// Invite adds targetID to the channel as a member. Any member can invite
+// to a public channel; private channels need ActionInviteToPrivate.
+func (s *MembershipService) Invite(ctx context.Context, tenantID, channelID, actorID, targetID uuid.UUID) error {
+	ch, err := s.channel(ctx, tenantID, channelID)
+	if err != nil {
+		return err
+	}
+	if ch.IsDM() {
+		return ErrDMMembership
+	}
+	if ch.IsArchived() {
+		return ErrChannelArchived
+	}
+
+	role, err := s.perms.role(ctx, channelID, actorID)
+	if err != nil {
+		return err
+	}
+	if ch.IsPrivate && !Can(role, ActionInviteToPrivate) {
+		return ErrInviteNotAllowed
+	}
+
+	// Re-inviting a member changes nothing and tells nobody.
+	already, err := s.membership.IsMember(ctx, channelID, targetID)
+	if err != nil {
+		return err
+	}
+	if already {
+		return nil
+	}
+	added, err := s.membership.AddMember(ctx, channelID, targetID, RoleMember)
+	if err != nil || !added {
+		return err
+	}
+	s.publishMember(ctx, memberInvitedEventType, channelID, actorID, targetID)
+	s.NotifyAdded(ctx, ch, actorID, []uuid.UUID{targetID})
+	return nil
+}
+
+// NotifyAdded tells each of userIDs, on all of their connections, that
+// actorID added them to ch, so clients can show the channel or DM without
+// polling. The actor is skipped.
+func (s *MembershipService) NotifyAdded(ctx context.Context, ch *models.Channel, actorID uuid.UUID, userIDs []uuid.UUID) {
+	for _, id := range userIDs {
+		if id == actorID {
+			continue
+		}
+		s.events.PublishToUser(ctx, id, websocket.OutboundEvent{
+			Type:      addedToChannelEventType,
+			ChannelID: ch.ID.String(),
+			Channel:   ch,
+			UserID:    actorID.String(),
+		})
+	}
+}
+
+// SetRole changes targetID's role on behalf of actorID.
+//
+//   - role "owner" transfers ownership: only the owner can do it, and they
+//     become an admin
+//   - otherwise the actor must outrank the target and can't grant a role
+//     above their own (canAssignRole)
+//   - nobody changes their own role this way
+func (s *MembershipService) SetRole(ctx context.Context, tenantID, channelID, actorID, targetID uuid.UUID, role string) error {
+	if !ValidRole(role) {
+		return ErrInvalidRole
+	}
+	ch, err := s.channel(ctx, tenantID, channelID)
+	if err != nil {
+		return err
+	}
+	if ch.IsDM() {
+		return ErrDMMembership
+	}
+
+	actorRole, targetRole, err := s.roles(ctx, channelID, actorID, targetID)
+	if err != nil {
+		return err
+	}
+
+	if role == RoleOwner {
+		if !Can(actorRole, ActionTransferOwnership) {
+			return ErrCannotManageMember
+		}
+		ok, err := s.membership.TransferOwnership(ctx, tenantID, channelID, actorID, targetID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrTargetNotMember
+		}
+		return nil
+	}
+
+	if !canAssignRole(actorRole, targetRole, role) {
+		return ErrCannotManageMember
+	}
+	if targetRole == role {
+		return nil
+	}
+	ok, err := s.membership.UpdateRole(ctx, tenantID, channelID, actorID, targetID, role)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTargetNotMember
+	}
+	return nil
+}
+
+// Remove kicks targetID out of the channel on behalf of actorID, who must
+// outrank them. Use Leave to remove yourself.
+func (s *MembershipService) Remove(ctx context.Context, tenantID, channelID, actorID, targetID uuid.UUID) error {
+	ch, err := s.channel(ctx, tenantID, channelID)
+	if err != nil {
+		return err
+	}
+	if ch.IsDM() {
+		return ErrDMMembership
+	}
+
+	actorRole, targetRole, err := s.roles(ctx, channelID, actorID, targetID)
+	if err != nil {
+		return err
+	}
+	if !canManageMember(actorRole, targetRole) {
+		return ErrCannotManageMember
+	}
+
+	ok, err := s.membership.Kick(ctx, tenantID, channelID, actorID, targetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTargetNotMember
+	}
+	s.removed(ctx, channelID, actorID, targetID)
+	s.publishMember(ctx, memberLeftEventType, channelID, actorID, targetID)
+	return nil
+}
+
+// removed cuts userID's live subscriptions to the channel on every node and
+// tells all of their connections why. The membership change is already
+// committed, so failures are only logged; stale subscribers go at reconnect.
+func (s *MembershipService) removed(ctx context.Context, channelID, actorID, userID uuid.UUID) {
+	if s.evictor != nil {
+		if err := s.evictor.EvictSubscribers(ctx, channelID, []uuid.UUID{userID}); err != nil {
+			s.logger.Error("failed to evict removed member", zap.Error(err))
+		}
+	}
+	s.events.PublishToUser(ctx, userID, websocket.OutboundEvent{
+		Type:      removedFromChannelEventType,
+		ChannelID: channelID.String(),
+		UserID:    actorID.String(),
+	})
+}
+
+// publishMember tells the channel's subscribers that targetID joined, left
+// or was invited, and who did it (the same user unless invited or kicked).
+func (s *MembershipService) publishMember(ctx context.Context, eventType string, channelID, actorID, targetID uuid.UUID) {
+	s.events.PublishToChannel(ctx, channelID, websocket.OutboundEvent{
+		Type:         eventType,
+		ChannelID:    channelID.String(),
+		UserID:       actorID.String(),
+		TargetUserID: targetID.String(),
+	})
+}
+
+// channel loads a channel in the tenant, or returns ErrChannelNotFound.
+func (s *MembershipService) channel(ctx context.Context, tenantID, channelID uuid.UUID) (*models.Channel, error) {
+	ch, err := s.channels.GetByID(ctx, tenantID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, ErrChannelNotFound
+	}
+	return ch, nil
+}
+
+// roles returns the actor's and target's roles for a member-management
+// action, rejecting self-management and targets outside the channel.
+func (s *MembershipService) roles(ctx context.Context, channelID, actorID, targetID uuid.UUID) (actorRole, targetRole string, err error) {
+	if actorID == targetID {
+		return "", "", ErrCannotManageMember
+	}
+	actorRole, err = s.perms.role(ctx, channelID, actorID)
+	if err != nil {
+		return "", "", err
+	}
+	targetRole, err = s.membership.GetRole(ctx, channelID, targetID)
+	if err != nil {
+		return "", "", err
+	}
+	if targetRole == "" {
+		return "", "", ErrTargetNotMember
+	}
+	return actorRole, targetRole, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/presence"
+	"github.com/lalith-99/echostream/internal/websocket"
+)
+
+const mentionEventType = "mention"
+
+var (
+	userMentionRe      = regexp.MustCompile(`<@([0-9a-fA-F-]{36})>`)
+	broadcastMentionRe = regexp.MustCompile(`(?:^|[^\w@])@(channel|here)\b`)
+)
+
+// PresenceChecker reports which users are online. @here uses it to pick
+// the members to notify.
+type PresenceChecker interface {
+	BulkStatus(ctx context.Context, userIDs []uuid.UUID) map[uuid.UUID]presence.Status
+}
+
+// parsedMentions is what a body asks for, before resolving against members.
+type parsedMentions struct {
+	users   []uuid.UUID
+	channel bool
+	here    bool
+}
+
+func (p parsedMentions) empty() bool {
+	return len(p.users) == 0 && !p.channel && !p.here
+}
+
+// parseMentions finds <@userID>, @channel and @here tokens in a body.
+// Malformed user IDs are ignored; duplicates are collapsed.
+func parseMentions(body string) parsedMentions {
+	var p parsedMentions
+	if !strings.Contains(body, "@") {
+		return p
+	}
+
+	seen := make(map[uuid.UUID]struct{})
+	for _, m := range userMentionRe.FindAllStringSubmatch(body, -1) {
+		id, err := uuid.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		if _, dup := seen[id]; !dup {
+			seen[id] = struct{}{}
+			p.users = append(p.users, id)
+		}
+	}
+	for _, m := range broadcastMentionRe.FindAllStringSubmatch(body, -1) {
+		switch m[1] {
+		case "channel":
+			p.channel = true
+		case "here":
+			p.here = true
+		}
+	}
+	return p
+}
+
+// resolveMentions turns a body's mention tokens into the channel members
+// to notify. Non-members and the sender are never included. A member
+// matched several ways keeps the most specific kind: user, then here,
+// then channel.
+func (s *MessageService) resolveMentions(ctx context.Context, channelID, senderID uuid.UUID, body string) ([]models.Mention, error) {
+	parsed := parseMentions(body)
+	if parsed.empty() {
+		return nil, nil
+	}
+
+	memberIDs, err := s.membership.ListMemberIDs(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make(map[uuid.UUID]string)
+	if parsed.channel {
+		for _, id := range memberIDs {
+			kinds[id] = models.MentionChannel
+		}
+	}
+	if parsed.here && s.presence != nil {
+		for id, status := range s.presence.BulkStatus(ctx, memberIDs) {
+			if status == presence.Online {
+				kinds[id] = models.MentionHere
+			}
+		}
+	}
+	if len(parsed.users) > 0 {
+		isMember := make(map[uuid.UUID]struct{}, len(memberIDs))
+		for _, id := range memberIDs {
+			isMember[id] = struct{}{}
+		}
+		for _, id := range parsed.users {
+			if _, ok := isMember[id]; ok {
+				kinds[id] = models.MentionUser
+			}
+		}
+	}
+	delete(kinds, senderID)
+
+	// Member order keeps the result deterministic.
+	mentions := make([]models.Mention, 0, len(kinds))
+	for _, id := range memberIDs {
+		if kind, ok := kinds[id]; ok {
+			mentions = append(mentions, models.Mention{UserID: id, Kind: kind})
+		}
+	}
+	return mentions, nil
+}
+
+// mentionEvents builds a mention event for each mentioned user, sent on
+// their personal stream whether or not they're subscribed to the channel.
+func mentionEvents(msg *models.Message, mentions []models.Mention) []topicEvent {
+	events := make([]topicEvent, len(mentions))
+	for i, m := range mentions {
+		events[i] = userEvent(m.UserID, websocket.OutboundEvent{
+			Type:        mentionEventType,
+			ChannelID:   msg.ChannelID.String(),
+			Message:     msg,
+			MentionKind: m.Kind,
+		})
+	}
+	return events
+}
+
+// ListMentions returns recent messages that mentioned userID, across all
+// channels they still belong to, newest first.
+func (s *MessageService) ListMentions(ctx context.Context, tenantID, userID uuid.UUID, before int64, limit int) ([]models.MentionResult, error) {
+	return s.messages.ListMentions(ctx, tenantID, userID, before, clampLimit(limit))
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseMentions(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	p := parseMentions("hi <@" + a.String() + "> <@" + b.String() + "> <@" + a.String() + "> @here")
+
+	if len(p.users) != 2 || p.users[0] != a || p.users[1] != b {
+		t.Fatalf("expected two distinct users in order, got %v", p.users)
+	}
+	if !p.here || p.channel {
+		t.Fatalf("expected only @here, got here=%v channel=%v", p.here, p.channel)
+	}
+}
+
+func TestParseMentions_BroadcastNeedsWordBoundary(t *testing.T) {
+	cases := map[string]bool{
+		"@channel heads up":     true,
+		"ping (@channel)":       true,
+		"email me@channel.io":   false,
+		"@channels are cool":    false,
+		"no mentions here":      false,
+		"<@not-a-uuid> @@here?": false,
+	}
+	for body, want := range cases {
+		p := parseMentions(body)
+		if got := p.channel || p.here; got != want {
+			t.Errorf("%q: got broadcast=%v, want %v", body, got, want)
+		}
+		if len(p.users) != 0 {
+			t.Errorf("%q: unexpected users %v", body, p.users)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 
 	"github.com/google/uuid"
@@ -13,11 +12,15 @@ import (
 )
 
 const (
-	maxMessageBody      = 4000 // bytes
-	defaultMessageLimit = 50
-	maxMessageLimit     = 100
-	messageEventType    = "message"
-	channelTopicPrefix  = "ch:"
+	maxMessageBody          = 4000 // bytes
+	maxClientMsgID          = 128  // bytes
+	maxAttachments          = 10
+	defaultMessageLimit     = 50
+	maxMessageLimit         = 100
+	messageEventType        = "message"
+	messageUpdatedEventType = "message_updated"
+	messageDeletedEventType = "message_deleted"
+	threadReplyEventType    = "thread_reply"
 )
 
 // Sentinel errors the handler can check with errors.Is().
@@ -25,33 +28,67 @@ var (
 	ErrNotMember   = errors.New("sender is not a member of this channel")
 	ErrEmptyBody   = errors.New("message body is empty")
 	ErrBodyTooLong = errors.New("message body exceeds maximum length")
+
+	ErrMessageNotFound = errors.New("message not found")
+	ErrNotSender       = errors.New("only the sender can modify this message")
+	ErrNotAllowed      = errors.New("not allowed to delete this message")
+	ErrInvalidParent   = errors.New("thread parent must be a top-level message in this channel")
+
+	ErrClientMsgIDTooLong = errors.New("client_msg_id exceeds maximum length")
+	ErrTooManyAttachments = errors.New("too many attachments")
+	ErrInvalidAttachment  = errors.New("attachments must be your own uploads to this channel")
+	ErrChannelArchived    = errors.New("channel is archived")
 )
 
-// EventPublisher pushes events to a pub/sub system (e.g., Redis).
-type EventPublisher interface {
-	Publish(ctx context.Context, channel string, payload []byte) error
+// SendOptions carries the optional parts of a message. The zero value
+// sends a plain top-level message.
+type SendOptions struct {
+	ParentID    int64  // reply in the thread rooted at this message
+	ClientMsgID string // idempotency key; a retry with the same key returns the original
+
+	AttachmentIDs []uuid.UUID // files previously uploaded to this channel by the sender
 }
 
 // MessageService owns the business rules for sending and listing messages.
 // It sits between the HTTP handler and the repositories.
 type MessageService struct {
 	messages   repository.MessageRepository
+	reactions  repository.ReactionRepository
 	membership repository.MembershipRepository
-	publisher  EventPublisher
+	perms      permissions
+	channels   repository.ChannelRepository
+	pins       repository.PinRepository
+	files      repository.FileRepository // nil = attachments disabled
+	presence   PresenceChecker           // nil = @here notifies nobody
+	events     *Events
+	relay      *OutboxRelay // nil = publish directly after commit
 	logger     *zap.Logger
 }
 
 // NewMessageService builds a MessageService.
 func NewMessageService(
 	messages repository.MessageRepository,
+	reactions repository.ReactionRepository,
 	membership repository.MembershipRepository,
+	channels repository.ChannelRepository,
+	pins repository.PinRepository,
+	files repository.FileRepository,
+	presence PresenceChecker,
 	publisher EventPublisher,
+	relay *OutboxRelay,
 	logger *zap.Logger,
 ) *MessageService {
 	return &MessageService{
 		messages:   messages,
+		reactions:  reactions,
 		membership: membership,
-		publisher:  publisher,
+		perms:      permissions{membership: membership},
+		channels:   channels,
+		pins:       pins,
+		files:      files,
+		presence:   presence,
+		events:     NewEvents(publisher, logger),
+		relay:      relay,
 		logger:     logger,
 	}
 }
@@ -59,67 +96,375 @@ func NewMessageService(
 // Send validates, persists, and publishes a message.
 //
 // Business rules enforced here:
-//  1. Body must not be empty
+//  1. Body must not be empty, unless the message carries attachments
 //  2. Body must not exceed maxMessageBody bytes
-//  3. Sender must be a member of the channel
+//  3. Sender must be a member of the channel, and it must not be archived
+//  4. A thread parent must be a live, top-level message in the same channel
+//  5. Attachments must be files the sender uploaded to this channel
 //
-// If persist succeeds but publish fails, we log and move on.
-// The message is already saved — real-time delivery is best-effort.
-func (s *MessageService) Send(ctx context.Context, tenantID, channelID, senderID uuid.UUID, body string) (*models.Message, error) {
-	// Rule 1: no empty messages
-	if body == "" {
-		return nil, ErrEmptyBody
+// <@userID>, @channel and @here are resolved to channel members, stored
+// with the message, and each mentioned user gets a mention event.
+//
+// A retry carrying an already-used ClientMsgID returns the stored message
+// without publishing it again; created is false in that case.
+//
+// With an OutboxRelay, the message (or thread_reply) and mention events
+// are stored in the same transaction and published at least once. Without
+// one they're published after the commit, and a failed publish is only
+// logged. Either way a reply's message_updated event for its parent is
+// published directly and is best-effort: it only refreshes counters that
+// clients also get from the next fetch of the thread.
+func (s *MessageService) Send(ctx context.Context, tenantID, channelID, senderID uuid.UUID, body string, opts SendOptions) (*models.Message, bool, error) {
+	// Rules 1 + 2: non-empty, capped size
+	if body != "" || len(opts.AttachmentIDs) == 0 {
+		if err := validateBody(body); err != nil {
+			return nil, false, err
+		}
 	}
-	// Rule 2: cap message size
-	if len(body) > maxMessageBody {
-		return nil, ErrBodyTooLong
+	if len(opts.AttachmentIDs) > maxAttachments {
+		return nil, false, ErrTooManyAttachments
+	}
+	if len(opts.ClientMsgID) > maxClientMsgID {
+		return nil, false, ErrClientMsgIDTooLong
 	}
 
-	// Rule 3: only channel members can post
-	ok, err := s.membership.IsMember(ctx, channelID, senderID)
+	// Rule 3: only channel members can post, and only while it's open
+	if err := s.requireMember(ctx, channelID, senderID); err != nil {
+		return nil, false, err
+	}
+	if _, err := s.openChannel(ctx, tenantID, channelID); err != nil {
+		return nil, false, err
+	}
+
+	// Rule 4: threads are one level deep
+	if err := s.requireThreadParent(ctx, tenantID, channelID, opts.ParentID); err != nil {
+		return nil, false, err
+	}
+
+	// Rule 5: attachments can't be borrowed from other users or channels
+	attachments, err := s.resolveAttachments(ctx, tenantID, channelID, senderID, opts.AttachmentIDs)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	if !ok {
-		return nil, ErrNotMember
+
+	mentions, err := s.resolveMentions(ctx, channelID, senderID, body)
+	if err != nil {
+		return nil, false, err
 	}
 
 	// Persist to Postgres
-	msg, err := s.messages.Create(ctx, tenantID, channelID, senderID, body)
+	params := repository.CreateMessageParams{
+		TenantID:      tenantID,
+		ChannelID:     channelID,
+		SenderID:      senderID,
+		Body:          body,
+		ParentID:      opts.ParentID,
+		ClientMsgID:   opts.ClientMsgID,
+		Mentions:      mentions,
+		AttachmentIDs: opts.AttachmentIDs,
+	}
+	if s.relay != nil {
+		params.Outbox = func(m *models.Message) ([]models.OutboxEvent, error) {
+			m.Attachments = attachments
+			return outboxEvents(newMessageEvents(m, mentions))
+		}
+	}
+	msg, created, err := s.messages.Create(ctx, params)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if !created {
+		// Retry of a send that already went through (and was published).
+		page := []models.Message{*msg}
+		if err := s.attachFiles(ctx, page); err != nil {
+			return nil, false, err
+		}
+		return &page[0], false, nil
 	}
+	msg.Attachments = attachments
 
 	// Fan out via Redis for real-time WebSocket delivery
-	if s.publisher != nil {
-		event := websocket.OutboundEvent{
-			Type:      messageEventType,
+	if s.relay != nil {
+		s.relay.Wake()
+	} else {
+		s.events.publishAll(ctx, newMessageEvents(msg, mentions))
+	}
+	if opts.ParentID > 0 {
+		s.publishParentUpdate(ctx, tenantID, channelID, opts.ParentID)
+	}
+
+	return msg, true, nil
+}
+
+// publishParentUpdate re-reads a thread parent so subscribers get its new
+// reply_count/last_reply_at after a reply is added or deleted.
+func (s *MessageService) publishParentUpdate(ctx context.Context, tenantID, channelID uuid.UUID, parentID int64) {
+	parent, err := s.messages.GetByID(ctx, tenantID, channelID, parentID)
+	if err != nil {
+		s.logger.Error("failed to reload thread parent", zap.Error(err))
+		return
+	}
+	if parent != nil {
+		s.events.PublishToChannel(ctx, channelID, websocket.OutboundEvent{
+			Type:      messageUpdatedEventType,
 			ChannelID: channelID.String(),
-			Message:   msg,
-		}
-		data, err := json.Marshal(event)
-		if err != nil {
-			s.logger.Error("failed to marshal message event", zap.Error(err))
-			return msg, nil // Message is saved, just can't broadcast it
-		}
-		if err := s.publisher.Publish(ctx, channelTopicPrefix+channelID.String(), data); err != nil {
-			s.logger.Error("publish failed (message is saved, delivery is best-effort)",
-				zap.Error(err),
-				zap.String("channel_id", channelID.String()),
-			)
-		}
+			Message:   parent,
+		})
 	}
+}
+
+// newMessageEvents builds the events announcing a new message: mentions,
+// then message (or thread_reply) for the channel.
+func newMessageEvents(msg *models.Message, mentions []models.Mention) []topicEvent {
+	eventType := messageEventType
+	if msg.ParentID != nil {
+		eventType = threadReplyEventType
+	}
+	return append(mentionEvents(msg, mentions), channelEvent(msg.ChannelID, websocket.OutboundEvent{
+		Type:      eventType,
+		ChannelID: msg.ChannelID.String(),
+		Message:   msg,
+	}))
+}
+
+// Edit replaces the body of an existing message.
+//
+// Same body rules as Send, plus:
+//   - the message must exist in this channel (and tenant)
+//   - only the original sender may edit it
+//   - the sender must still be a member of the channel
+//   - the channel must not be archived
+//
+// The previous body is kept in the edit history by the repository.
+// Mentions are resolved again from the new body and replace the old ones.
+func (s *MessageService) Edit(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, editorID uuid.UUID, body string) (*models.Message, error) {
+	if err := validateBody(body); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.messages.GetByID(ctx, tenantID, channelID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrMessageNotFound
+	}
+	if existing.DeletedAt != nil {
+		return nil, ErrMessageNotFound
+	}
+	if existing.SenderID != editorID {
+		return nil, ErrNotSender
+	}
+
+	if err := s.requireMember(ctx, channelID, editorID); err != nil {
+		return nil, err
+	}
+	if _, err := s.openChannel(ctx, tenantID, channelID); err != nil {
+		return nil, err
+	}
+
+	mentions, err := s.resolveMentions(ctx, channelID, editorID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, added, err := s.messages.Edit(ctx, tenantID, channelID, messageID, editorID, body, mentions)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		// Deleted between the lookup and the update.
+		return nil, ErrMessageNotFound
+	}
+	// Subscribers replace the whole message, so keep its attachments.
+	page := []models.Message{*msg}
+	if err := s.attachFiles(ctx, page); err != nil {
+		return nil, err
+	}
+	msg = &page[0]
+
+	s.events.PublishToChannel(ctx, channelID, websocket.OutboundEvent{
+		Type:      messageUpdatedEventType,
+		ChannelID: channelID.String(),
+		Message:   msg,
+	})
+	// Only users the edit newly mentions are notified; the rest already were.
+	s.events.publishAll(ctx, mentionEvents(msg, added))
 
 	return msg, nil
 }
 
+// Delete soft-deletes a message.
+//
+// The sender can delete their own message; a channel "admin" can delete
+// anyone's (moderation). Either way the actor must be a member of the
+// channel, and the channel must not be archived. The row stays as a
+// tombstone so cursor pagination is unaffected. A pinned message is
+// unpinned as part of the delete, and a deleted reply no longer counts
+// towards its parent's replies.
+func (s *MessageService) Delete(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, actorID uuid.UUID) error {
+	existing, err := s.messages.GetByID(ctx, tenantID, channelID, messageID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.DeletedAt != nil {
+		return ErrMessageNotFound
+	}
+
+	role, err := s.perms.role(ctx, channelID, actorID)
+	if err != nil {
+		return err
+	}
+	if existing.SenderID != actorID && !Can(role, ActionDeleteAnyMessage) {
+		return ErrNotAllowed
+	}
+	if _, err := s.openChannel(ctx, tenantID, channelID); err != nil {
+		return err
+	}
+
+	msg, unpinned, err := s.messages.SoftDelete(ctx, tenantID, channelID, messageID, actorID)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		// Someone else deleted it first.
+		return ErrMessageNotFound
+	}
+
+	s.events.PublishToChannel(ctx, channelID, websocket.OutboundEvent{
+		Type:      messageDeletedEventType,
+		ChannelID: channelID.String(),
+		Message:   msg,
+	})
+	if unpinned {
+		s.publishPin(ctx, pinRemovedEventType, channelID, messageID, actorID)
+	}
+	if msg.ParentID != nil {
+		s.publishParentUpdate(ctx, tenantID, channelID, *msg.ParentID)
+	}
+
+	return nil
+}
+
 // List returns messages with cursor pagination. Applies default/max limits.
-func (s *MessageService) List(ctx context.Context, tenantID, channelID uuid.UUID, before int64, limit int) ([]models.Message, error) {
+// Reaction summaries are attached relative to viewerID.
+func (s *MessageService) List(ctx context.Context, tenantID, channelID, viewerID uuid.UUID, before int64, limit int) ([]models.Message, error) {
+	messages, err := s.messages.ListByChannel(ctx, tenantID, channelID, before, clampLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReactions(ctx, messages, viewerID); err != nil {
+		return nil, err
+	}
+	if err := s.attachFiles(ctx, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ListAfter returns top-level messages newer than after, oldest first,
+// so a reconnecting client can append them in order. Unlike List it
+// checks membership, since it also backs websocket replay.
+func (s *MessageService) ListAfter(ctx context.Context, tenantID, channelID, viewerID uuid.UUID, after int64, limit int) ([]models.Message, error) {
+	if err := s.requireMember(ctx, channelID, viewerID); err != nil {
+		return nil, err
+	}
+	messages, err := s.messages.ListAfter(ctx, tenantID, channelID, after, clampLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReactions(ctx, messages, viewerID); err != nil {
+		return nil, err
+	}
+	if err := s.attachFiles(ctx, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ListReplies returns a thread's replies with the same cursor semantics as List.
+// Only members can read them.
+func (s *MessageService) ListReplies(ctx context.Context, tenantID, channelID, viewerID uuid.UUID, parentID int64, before int64, limit int) ([]models.Message, error) {
+	if err := s.requireMember(ctx, channelID, viewerID); err != nil {
+		return nil, err
+	}
+	parent, err := s.messages.GetByID(ctx, tenantID, channelID, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, ErrMessageNotFound
+	}
+	replies, err := s.messages.ListReplies(ctx, tenantID, channelID, parentID, before, clampLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReactions(ctx, replies, viewerID); err != nil {
+		return nil, err
+	}
+	if err := s.attachFiles(ctx, replies); err != nil {
+		return nil, err
+	}
+	return replies, nil
+}
+
+func clampLimit(limit int) int {
 	if limit < 1 {
-		limit = defaultMessageLimit
+		return defaultMessageLimit
 	}
 	if limit > maxMessageLimit {
-		limit = maxMessageLimit
+		return maxMessageLimit
+	}
+	return limit
+}
+
+func validateBody(body string) error {
+	if body == "" {
+		return ErrEmptyBody
+	}
+	if len(body) > maxMessageBody {
+		return ErrBodyTooLong
+	}
+	return nil
+}
+
+func (s *MessageService) requireMember(ctx context.Context, channelID, userID uuid.UUID) error {
+	ok, err := s.membership.IsMember(ctx, channelID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotMember
+	}
+	return nil
+}
+
+// requireThreadParent checks that parentID, if set, is a live top-level
+// message in the channel, so replies stay one level deep.
+func (s *MessageService) requireThreadParent(ctx context.Context, tenantID, channelID uuid.UUID, parentID int64) error {
+	if parentID == 0 {
+		return nil
+	}
+	parent, err := s.messages.GetByID(ctx, tenantID, channelID, parentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil || parent.DeletedAt != nil || parent.ParentID != nil {
+		return ErrInvalidParent
+	}
+	return nil
+}
+
+// openChannel loads the channel for a write, returning ErrChannelArchived
+// if it's archived. Every path that changes messages, reactions or pins
+// goes through it. The channel may be nil if the lookup found nothing.
+func (s *MessageService) openChannel(ctx context.Context, tenantID, channelID uuid.UUID) (*models.Channel, error) {
+	ch, err := s.channels.GetByID(ctx, tenantID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch != nil && ch.IsArchived() {
+		return nil, ErrChannelArchived
 	}
-	return s.messages.ListByChannel(ctx, tenantID, channelID, before, limit)
+	return ch, nil
 }
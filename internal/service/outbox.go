@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	outboxBatch     = 100
+	outboxLease     = 30 * time.Second // a batch stops at its first failed publish, so it ends well within this
+	outboxRetryMin  = time.Second
+	outboxRetryMax  = time.Minute
+	outboxRetention = 24 * time.Hour // how long sent rows are kept
+)
+
+// OutboxRelay publishes events that repositories stored in the outbox in
+// the same transaction as the write they announce. Every event is
+// published at least once: a failed publish is retried with backoff, and a
+// relay that dies mid-batch leaves its rows to be reclaimed when the lease
+// lapses. Events that needed a retry can arrive out of order.
+type OutboxRelay struct {
+	outbox    repository.OutboxRepository
+	publisher EventPublisher
+	wake      chan struct{}
+	logger    *zap.Logger
+}
+
+// NewOutboxRelay builds an OutboxRelay.
+func NewOutboxRelay(outbox repository.OutboxRepository, publisher EventPublisher, logger *zap.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    outbox,
+		publisher: publisher,
+		wake:      make(chan struct{}, 1),
+		logger:    logger,
+	}
+}
+
+// Wake asks the relay loop to run now rather than at its next tick, e.g.
+// right after committing outbox rows. It never blocks.
+func (r *OutboxRelay) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default: // a run is already pending
+	}
+}
+
+// Woken is signalled by Wake.
+func (r *OutboxRelay) Woken() <-chan struct{} {
+	return r.wake
+}
+
+// RelayPending publishes due outbox events until none are left and
+// returns how many were sent. Claims are leased, so every replica can
+// run it.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		batch, err := r.outbox.ClaimPending(ctx, outboxBatch, outboxLease)
+		if err != nil {
+			return total, err
+		}
+
+		sent, failed := r.publishBatch(ctx, batch)
+		// If this fails the events go out again once the lease lapses.
+		if err := r.outbox.MarkSent(ctx, sent); err != nil {
+			return total, err
+		}
+		total += len(sent)
+
+		if failed || len(batch) < outboxBatch || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// publishBatch publishes claimed events in order and returns the IDs that
+// went out. It stops at the first failure: Redis is likely down, and each
+// further attempt could block until the client times out, letting the
+// batch outlive its lease so another replica re-sends it. The failed
+// event is rescheduled with backoff and the rest are released for the
+// next run.
+func (r *OutboxRelay) publishBatch(ctx context.Context, batch []models.OutboxEvent) (sent []int64, failed bool) {
+	sent = make([]int64, 0, len(batch))
+	for i, ev := range batch {
+		err := r.publisher.Publish(ctx, ev.Topic, ev.Payload)
+		if err == nil {
+			sent = append(sent, ev.ID)
+			continue
+		}
+
+		retryIn := outboxBackoff(ev.Attempts)
+		r.logger.Warn("outbox publish failed, will retry",
+			zap.Int64("outbox_id", ev.ID),
+			zap.Int("attempts", ev.Attempts+1),
+			zap.Duration("retry_in", retryIn),
+			zap.Error(err),
+		)
+		if err := r.outbox.MarkFailed(ctx, ev.ID, err.Error(), retryIn); err != nil {
+			r.logger.Error("failed to mark outbox event failed", zap.Error(err))
+		}
+		rest := make([]int64, 0, len(batch)-i-1)
+		for _, ev := range batch[i+1:] {
+			rest = append(rest, ev.ID)
+		}
+		if err := r.outbox.Release(ctx, rest); err != nil {
+			r.logger.Error("failed to release outbox events", zap.Error(err))
+		}
+		return sent, true
+	}
+	return sent, false
+}
+
+// Prune deletes events sent more than outboxRetention ago.
+func (r *OutboxRelay) Prune(ctx context.Context) (int64, error) {
+	return r.outbox.PruneSent(ctx, outboxRetention)
+}
+
+// outboxBackoff is the wait before retrying an event that has already
+// failed attempts times: 1s, 2s, 4s ... up to outboxRetryMax.
+func outboxBackoff(attempts int) time.Duration {
+	if attempts >= 6 { // 1s << 6 already exceeds outboxRetryMax
+		return outboxRetryMax
+	}
+	return min(outboxRetryMin<<attempts, outboxRetryMax)
+}
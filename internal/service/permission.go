@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/repository"
+)
+
+// Channel roles, from least to most privileged. Each channel has at most
+// one owner; ownership moves with TransferOwnership rather than SetRole.
+const (
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+	RoleOwner  = "owner"
+)
+
+var roleRank = map[string]int{
+	RoleMember: 1,
+	RoleAdmin:  2,
+	RoleOwner:  3,
+}
+
+// Action is something a channel member may or may not be allowed to do.
+type Action int
+
+const (
+	ActionManageChannel     Action = iota // rename, topic, purpose, archive, convert
+	ActionManagePins                      // pin and unpin where pins_admin_only is set
+	ActionDeleteAnyMessage                // delete other people's messages
+	ActionInviteToPrivate                 // add people to a private channel
+	ActionManageMembers                   // change roles and remove members (see canManageMember)
+	ActionTransferOwnership               // hand the channel on; the holder must before leaving
+)
+
+// minRole is the least privileged role allowed to perform each action.
+// Every channel permission decision goes through this table.
+var minRole = map[Action]string{
+	ActionManageChannel:     RoleAdmin,
+	ActionManagePins:        RoleAdmin,
+	ActionDeleteAnyMessage:  RoleAdmin,
+	ActionInviteToPrivate:   RoleAdmin,
+	ActionManageMembers:     RoleAdmin,
+	ActionTransferOwnership: RoleOwner,
+}
+
+// ValidRole reports whether role is a known channel role.
+func ValidRole(role string) bool {
+	return roleRank[role] > 0
+}
+
+// Can reports whether a member with role may perform a.
+func Can(role string, a Action) bool {
+	return ValidRole(role) && roleRank[role] >= roleRank[minRole[a]]
+}
+
+// canManageMember reports whether actor may change target's role or
+// remove them: they need ActionManageMembers and must strictly outrank
+// the target, so admins manage members and only the owner manages admins.
+func canManageMember(actor, target string) bool {
+	return Can(actor, ActionManageMembers) && roleRank[actor] > roleRank[target]
+}
+
+// canAssignRole reports whether actor may give target the role: they
+// must be able to manage target and can't grant more than they hold.
+// Ownership is never assigned this way; see ActionTransferOwnership.
+func canAssignRole(actor, target, role string) bool {
+	return canManageMember(actor, target) && roleRank[role] <= roleRank[actor]
+}
+
+// permissions looks up a user's channel role for the checks above.
+type permissions struct {
+	membership repository.MembershipRepository
+}
+
+// role returns userID's role in the channel, or ErrNotMember.
+func (p permissions) role(ctx context.Context, channelID, userID uuid.UUID) (string, error) {
+	role, err := p.membership.GetRole(ctx, channelID, userID)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		return "", ErrNotMember
+	}
+	return role, nil
+}
+
+// require returns userID's role if it allows a, ErrNotMember if they
+// aren't in the channel, and denied otherwise.
+func (p permissions) require(ctx context.Context, channelID, userID uuid.UUID, a Action, denied error) (string, error) {
+	role, err := p.role(ctx, channelID, userID)
+	if err != nil {
+		return "", err
+	}
+	if !Can(role, a) {
+		return "", denied
+	}
+	return role, nil
+}
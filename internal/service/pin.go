@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/websocket"
+)
+
+const (
+	pinAddedEventType   = "pin_added"
+	pinRemovedEventType = "pin_removed"
+)
+
+var ErrPinAdminOnly = errors.New("only channel admins can change pins in this channel")
+
+// Pin pins a live message in the channel.
+//
+// Rules: the actor must be a channel member, and an admin if the channel
+// has pins_admin_only set. The channel must not be archived. Pinning an
+// already-pinned message is a no-op (no second event is published). Pins
+// survive edits.
+func (s *MessageService) Pin(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, userID uuid.UUID) error {
+	if err := s.checkPinTarget(ctx, tenantID, channelID, messageID, userID); err != nil {
+		return err
+	}
+
+	pinned, err := s.pins.Pin(ctx, channelID, messageID, userID)
+	if err != nil {
+		return err
+	}
+	if pinned {
+		s.publishPin(ctx, pinAddedEventType, channelID, messageID, userID)
+	}
+	return nil
+}
+
+// Unpin removes a pin, under the same rules as Pin. Unpinning a message
+// that isn't pinned is a no-op.
+func (s *MessageService) Unpin(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, userID uuid.UUID) error {
+	if err := s.checkPinTarget(ctx, tenantID, channelID, messageID, userID); err != nil {
+		return err
+	}
+
+	unpinned, err := s.pins.Unpin(ctx, channelID, messageID)
+	if err != nil {
+		return err
+	}
+	if unpinned {
+		s.publishPin(ctx, pinRemovedEventType, channelID, messageID, userID)
+	}
+	return nil
+}
+
+// ListPins returns the channel's pinned messages, most recently pinned
+// first. Only members can see them.
+func (s *MessageService) ListPins(ctx context.Context, tenantID, channelID, viewerID uuid.UUID) ([]models.PinnedMessage, error) {
+	if err := s.requireMember(ctx, channelID, viewerID); err != nil {
+		return nil, err
+	}
+	pins, err := s.pins.List(ctx, tenantID, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]models.Message, len(pins))
+	for i := range pins {
+		messages[i] = pins[i].Message
+	}
+	if err := s.attachReactions(ctx, messages, viewerID); err != nil {
+		return nil, err
+	}
+	if err := s.attachFiles(ctx, messages); err != nil {
+		return nil, err
+	}
+	for i := range pins {
+		pins[i].Message = messages[i]
+	}
+	return pins, nil
+}
+
+func (s *MessageService) checkPinTarget(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, userID uuid.UUID) error {
+	role, err := s.perms.role(ctx, channelID, userID)
+	if err != nil {
+		return err
+	}
+	ch, err := s.openChannel(ctx, tenantID, channelID)
+	if err != nil {
+		return err
+	}
+	if ch != nil && ch.PinsAdminOnly && !Can(role, ActionManagePins) {
+		return ErrPinAdminOnly
+	}
+
+	msg, err := s.messages.GetByID(ctx, tenantID, channelID, messageID)
+	if err != nil {
+		return err
+	}
+	if msg == nil || msg.DeletedAt != nil {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+func (s *MessageService) publishPin(ctx context.Context, eventType string, channelID uuid.UUID, messageID int64, userID uuid.UUID) {
+	s.events.PublishToChannel(ctx, channelID, websocket.OutboundEvent{
+		Type:      eventType,
+		ChannelID: channelID.String(),
+		MessageID: messageID,
+		UserID:    userID.String(),
+	})
+}
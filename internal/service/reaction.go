@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/websocket"
+)
+
+const (
+	maxEmojiLen              = 64 // bytes; fits shortcodes and multi-codepoint emoji
+	reactionAddedEventType   = "reaction_added"
+	reactionRemovedEventType = "reaction_removed"
+)
+
+var ErrInvalidEmoji = errors.New("emoji must be 1-64 bytes with no whitespace")
+
+// AddReaction reacts to a message with an emoji.
+//
+// Rules: the reactor must be a channel member, the channel must not be
+// archived, and the message must be a live message in this channel.
+// Reacting twice with the same emoji is a no-op (no second event is
+// published).
+func (s *MessageService) AddReaction(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, userID uuid.UUID, emoji string) error {
+	if err := s.checkReactionTarget(ctx, tenantID, channelID, messageID, userID, emoji); err != nil {
+		return err
+	}
+
+	added, err := s.reactions.Add(ctx, messageID, userID, emoji)
+	if err != nil {
+		return err
+	}
+	if added {
+		s.publishReaction(ctx, reactionAddedEventType, channelID, messageID, userID, emoji)
+	}
+	return nil
+}
+
+// RemoveReaction takes back the caller's own reaction. Removing a reaction
+// that doesn't exist is a no-op.
+func (s *MessageService) RemoveReaction(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, userID uuid.UUID, emoji string) error {
+	if err := s.checkReactionTarget(ctx, tenantID, channelID, messageID, userID, emoji); err != nil {
+		return err
+	}
+
+	removed, err := s.reactions.Remove(ctx, messageID, userID, emoji)
+	if err != nil {
+		return err
+	}
+	if removed {
+		s.publishReaction(ctx, reactionRemovedEventType, channelID, messageID, userID, emoji)
+	}
+	return nil
+}
+
+func (s *MessageService) checkReactionTarget(ctx context.Context, tenantID, channelID uuid.UUID, messageID int64, userID uuid.UUID, emoji string) error {
+	if !validEmoji(emoji) {
+		return ErrInvalidEmoji
+	}
+	if err := s.requireMember(ctx, channelID, userID); err != nil {
+		return err
+	}
+	if _, err := s.openChannel(ctx, tenantID, channelID); err != nil {
+		return err
+	}
+	msg, err := s.messages.GetByID(ctx, tenantID, channelID, messageID)
+	if err != nil {
+		return err
+	}
+	if msg == nil || msg.DeletedAt != nil {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+func (s *MessageService) publishReaction(ctx context.Context, eventType string, channelID uuid.UUID, messageID int64, userID uuid.UUID, emoji string) {
+	s.events.PublishToChannel(ctx, channelID, websocket.OutboundEvent{
+		Type:      eventType,
+		ChannelID: channelID.String(),
+		MessageID: messageID,
+		UserID:    userID.String(),
+		Emoji:     emoji,
+	})
+}
+
+// attachReactions fills in Reactions for a page of messages with one query.
+func (s *MessageService) attachReactions(ctx context.Context, messages []models.Message, viewerID uuid.UUID) error {
+	if s.reactions == nil || len(messages) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	summaries, err := s.reactions.Summaries(ctx, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		messages[i].Reactions = summaries[messages[i].ID]
+	}
+	return nil
+}
+
+// validEmoji accepts shortcodes ("thumbsup") and raw emoji ("👍"), but
+// rejects empty values, whitespace and anything absurdly long.
+func validEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > maxEmojiLen {
+		return false
+	}
+	return !strings.ContainsFunc(emoji, unicode.IsSpace)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/websocket"
+)
+
+const readMarkerEventType = "read_marker"
+
+// MarkRead moves the reader's cursor in a channel up to messageID.
+//
+// Rules: the reader must be a member and the message must exist in this
+// channel (a deleted message is still a valid cursor). Cursors never move
+// backwards; a stale mark is a silent no-op. When the cursor does move,
+// the reader's other devices get a read_marker event.
+func (s *MessageService) MarkRead(ctx context.Context, tenantID, channelID, userID uuid.UUID, messageID int64) error {
+	if err := s.requireMember(ctx, channelID, userID); err != nil {
+		return err
+	}
+
+	msg, err := s.messages.GetByID(ctx, tenantID, channelID, messageID)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return ErrMessageNotFound
+	}
+
+	moved, err := s.membership.MarkRead(ctx, channelID, userID, messageID)
+	if err != nil {
+		return err
+	}
+	if moved {
+		s.events.PublishToUser(ctx, userID, websocket.OutboundEvent{
+			Type:      readMarkerEventType,
+			ChannelID: channelID.String(),
+			MessageID: messageID,
+		})
+	}
+	return nil
+}
+
+// Unreads returns unread and mention counts for every channel the user
+// belongs to, including DMs.
+func (s *MessageService) Unreads(ctx context.Context, tenantID, userID uuid.UUID) ([]models.ChannelUnread, error) {
+	return s.membership.Unreads(ctx, tenantID, userID)
+}
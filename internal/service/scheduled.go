@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+	"github.com/lalith-99/echostream/internal/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	maxScheduleAhead = 120 * 24 * time.Hour
+	deliveryBatch    = 50
+	// deliveryLease must comfortably exceed the time to deliver one batch;
+	// a lapsed lease lets another replica retry the row.
+	deliveryLease = time.Minute
+
+	scheduledMessageFailedEventType = "scheduled_message_failed"
+	scheduledClientMsgIDPrefix      = "scheduled:"
+)
+
+var (
+	ErrSendAtInPast        = errors.New("send_at must be in the future")
+	ErrSendAtTooFar        = errors.New("send_at is too far in the future")
+	ErrScheduledNotFound   = errors.New("scheduled message not found")
+	ErrScheduledNotPending = errors.New("scheduled message is no longer pending")
+)
+
+// rejectedSendErrors are Send failures that won't succeed on retry; the
+// scheduled message is marked failed instead of being retried.
+var rejectedSendErrors = []error{
+	ErrNotMember,
+	ErrEmptyBody,
+	ErrBodyTooLong,
+	ErrInvalidParent,
+	ErrTooManyAttachments,
+	ErrInvalidAttachment,
+	ErrChannelArchived,
+}
+
+// ScheduleService queues messages for later and delivers them through
+// MessageService.Send when they're due, so delivery follows exactly the
+// same rules (membership included) as sending right away.
+type ScheduleService struct {
+	scheduled repository.ScheduledMessageRepository
+	messages  *MessageService
+	logger    *zap.Logger
+}
+
+// NewScheduleService builds a ScheduleService.
+func NewScheduleService(scheduled repository.ScheduledMessageRepository, messages *MessageService, logger *zap.Logger) *ScheduleService {
+	return &ScheduleService{scheduled: scheduled, messages: messages, logger: logger}
+}
+
+// Schedule queues a message to be sent at sendAt.
+//
+// All of Send's rules (body, membership, thread parent, attachments) are
+// checked now so mistakes fail fast, and again at delivery, since any of
+// them can change in between. A retry carrying an already-used
+// ClientMsgID returns the original scheduled message with created false.
+func (s *ScheduleService) Schedule(ctx context.Context, tenantID, channelID, senderID uuid.UUID, body string, sendAt time.Time, opts SendOptions) (*models.ScheduledMessage, bool, error) {
+	if body != "" || len(opts.AttachmentIDs) == 0 {
+		if err := validateBody(body); err != nil {
+			return nil, false, err
+		}
+	}
+	if len(opts.AttachmentIDs) > maxAttachments {
+		return nil, false, ErrTooManyAttachments
+	}
+	if len(opts.ClientMsgID) > maxClientMsgID {
+		return nil, false, ErrClientMsgIDTooLong
+	}
+	if err := validateSendAt(sendAt); err != nil {
+		return nil, false, err
+	}
+	if err := s.messages.requireMember(ctx, channelID, senderID); err != nil {
+		return nil, false, err
+	}
+	if _, err := s.messages.openChannel(ctx, tenantID, channelID); err != nil {
+		return nil, false, err
+	}
+	if err := s.messages.requireThreadParent(ctx, tenantID, channelID, opts.ParentID); err != nil {
+		return nil, false, err
+	}
+	if _, err := s.messages.resolveAttachments(ctx, tenantID, channelID, senderID, opts.AttachmentIDs); err != nil {
+		return nil, false, err
+	}
+
+	m := &models.ScheduledMessage{
+		TenantID:      tenantID,
+		ChannelID:     channelID,
+		SenderID:      senderID,
+		Body:          body,
+		AttachmentIDs: opts.AttachmentIDs,
+		SendAt:        sendAt,
+	}
+	if opts.ParentID > 0 {
+		m.ParentID = &opts.ParentID
+	}
+	if opts.ClientMsgID != "" {
+		m.ClientMsgID = &opts.ClientMsgID
+	}
+	return s.scheduled.Create(ctx, m)
+}
+
+// List returns the user's scheduled messages that haven't been sent.
+func (s *ScheduleService) List(ctx context.Context, tenantID, senderID uuid.UUID) ([]models.ScheduledMessage, error) {
+	return s.scheduled.ListBySender(ctx, tenantID, senderID)
+}
+
+// ScheduledUpdate carries the fields of an edit. Nil fields are unchanged.
+type ScheduledUpdate struct {
+	Body   *string
+	SendAt *time.Time
+}
+
+// Update edits a pending message's body and/or send time. Only the
+// sender can see or edit it. A message the scheduler has already picked
+// up, or that failed, can no longer be changed.
+func (s *ScheduleService) Update(ctx context.Context, tenantID, senderID, id uuid.UUID, u ScheduledUpdate) (*models.ScheduledMessage, error) {
+	m, err := s.scheduled.GetByID(ctx, tenantID, senderID, id)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrScheduledNotFound
+	}
+	if m.Status != models.ScheduledPending {
+		return nil, ErrScheduledNotPending
+	}
+
+	body, sendAt := m.Body, m.SendAt
+	if u.Body != nil {
+		body = *u.Body
+		if body != "" || len(m.AttachmentIDs) == 0 {
+			if err := validateBody(body); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if u.SendAt != nil {
+		sendAt = *u.SendAt
+		if err := validateSendAt(sendAt); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.scheduled.Update(ctx, tenantID, senderID, id, body, sendAt)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		// Claimed for delivery (or cancelled) since we read it.
+		return nil, ErrScheduledNotPending
+	}
+	return updated, nil
+}
+
+// Cancel deletes a pending or failed scheduled message.
+func (s *ScheduleService) Cancel(ctx context.Context, tenantID, senderID, id uuid.UUID) error {
+	deleted, err := s.scheduled.Delete(ctx, tenantID, senderID, id)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return nil
+	}
+	m, err := s.scheduled.GetByID(ctx, tenantID, senderID, id)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return ErrScheduledNotFound
+	}
+	return ErrScheduledNotPending
+}
+
+// DeliverDue sends every scheduled message that is due, in batches, and
+// returns how many were processed. Safe to run on every replica at once.
+//
+// A message Send rejects (the sender left the channel, the thread parent
+// was deleted, ...) is marked failed and the sender is told. Any other
+// error leaves the claim to lapse, so the message is retried; the
+// scheduled:<id> client_msg_id keeps a retry from posting twice.
+func (s *ScheduleService) DeliverDue(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		due, err := s.scheduled.ClaimDue(ctx, deliveryBatch, deliveryLease)
+		if err != nil {
+			return total, err
+		}
+		for i := range due {
+			s.deliver(ctx, &due[i])
+		}
+		total += len(due)
+		if len(due) < deliveryBatch || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+func (s *ScheduleService) deliver(ctx context.Context, m *models.ScheduledMessage) {
+	opts := SendOptions{
+		ClientMsgID:   scheduledClientMsgIDPrefix + m.ID.String(),
+		AttachmentIDs: m.AttachmentIDs,
+	}
+	if m.ParentID != nil {
+		opts.ParentID = *m.ParentID
+	}
+
+	msg, _, err := s.messages.Send(ctx, m.TenantID, m.ChannelID, m.SenderID, m.Body, opts)
+	if err != nil {
+		if !isRejectedSend(err) {
+			s.logger.Error("scheduled delivery failed, will retry",
+				zap.String("scheduled_id", m.ID.String()),
+				zap.Error(err),
+			)
+			return
+		}
+		if err := s.scheduled.MarkFailed(ctx, m.ID, err.Error()); err != nil {
+			s.logger.Error("failed to mark scheduled message failed", zap.Error(err))
+			return
+		}
+		reason := err.Error()
+		m.Status, m.Error = models.ScheduledFailed, &reason
+		s.messages.events.PublishToUser(ctx, m.SenderID, websocket.OutboundEvent{
+			Type:      scheduledMessageFailedEventType,
+			ChannelID: m.ChannelID.String(),
+			Message:   m,
+			Error:     reason,
+		})
+		return
+	}
+
+	if err := s.scheduled.MarkSent(ctx, m.ID, msg.ID); err != nil {
+		// The message is out; the lease will lapse and the retry will be
+		// deduplicated by client_msg_id, then marked sent.
+		s.logger.Error("failed to mark scheduled message sent", zap.Error(err))
+	}
+}
+
+func isRejectedSend(err error) bool {
+	for _, target := range rejectedSendErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSendAt(sendAt time.Time) error {
+	now := time.Now()
+	if !sendAt.After(now) {
+		return ErrSendAtInPast
+	}
+	if sendAt.Sub(now) > maxScheduleAhead {
+		return ErrSendAtTooFar
+	}
+	return nil
+}
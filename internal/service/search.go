@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
+	"github.com/lalith-99/echostream/internal/repository"
+)
+
+const (
+	maxSearchQuery   = 500 // bytes
+	searchDateLayout = "2006-01-02"
+)
+
+var (
+	ErrEmptySearch       = errors.New("search query is empty")
+	ErrSearchTooLong     = errors.New("search query exceeds maximum length")
+	ErrInvalidSearchDate = errors.New("before:/after: dates must be YYYY-MM-DD")
+)
+
+// Search runs a Slack-style message search for viewerID.
+//
+// Supported syntax (combined with AND):
+//
+//	budget "q3 plan"      words and quoted phrases (full-text)
+//	in:general in:#ops    channel by name, or in:<channel uuid>
+//	from:me from:@ana     sender: me, a user uuid, an email or a display name
+//	before:2024-05-01     created before that day (UTC)
+//	after:2024-04-01      created after that day (UTC)
+//
+// Results only include channels the viewer is a member of.
+func (s *MessageService) Search(ctx context.Context, tenantID, viewerID uuid.UUID, raw string, before int64, limit int) ([]models.SearchResult, error) {
+	if len(raw) > maxSearchQuery {
+		return nil, ErrSearchTooLong
+	}
+	q, err := parseSearchQuery(raw, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if q == (repository.MessageSearch{}) {
+		return nil, ErrEmptySearch
+	}
+	return s.messages.Search(ctx, tenantID, viewerID, q, before, clampLimit(limit))
+}
+
+// parseSearchQuery splits raw into operators and free text. Unknown
+// operators ("foo:bar") are treated as ordinary search text.
+func parseSearchQuery(raw string, viewerID uuid.UUID) (repository.MessageSearch, error) {
+	var q repository.MessageSearch
+	var text []string
+
+	for _, tok := range tokenizeSearch(raw) {
+		key, value, isOp := strings.Cut(tok, ":")
+		value = unquote(value)
+		if !isOp || value == "" {
+			text = append(text, tok)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "in":
+			value = strings.TrimPrefix(value, "#")
+			if id, err := uuid.Parse(value); err == nil {
+				q.ChannelID = id
+			} else {
+				q.ChannelName = value
+			}
+		case "from":
+			value = strings.TrimPrefix(value, "@")
+			if strings.EqualFold(value, "me") {
+				q.SenderID = viewerID
+			} else if id, err := uuid.Parse(value); err == nil {
+				q.SenderID = id
+			} else {
+				q.Sender = value
+			}
+		case "before":
+			day, err := time.Parse(searchDateLayout, value)
+			if err != nil {
+				return q, ErrInvalidSearchDate
+			}
+			q.Before = day
+		case "after":
+			day, err := time.Parse(searchDateLayout, value)
+			if err != nil {
+				return q, ErrInvalidSearchDate
+			}
+			// "after:2024-04-01" means from 2024-04-02 onwards, like Slack.
+			q.After = day.AddDate(0, 0, 1)
+		default:
+			text = append(text, tok)
+		}
+	}
+
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// tokenizeSearch splits on whitespace, keeping double-quoted spans
+// (including operator values like from:"Ana Lima") in a single token.
+func tokenizeSearch(raw string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuote := false
+
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n'):
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
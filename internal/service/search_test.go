@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/repository"
+)
+
+func TestParseSearchQuery_Operators(t *testing.T) {
+	viewer := uuid.New()
+	q, err := parseSearchQuery(`budget "q3 plan" in:#finance from:me before:2024-05-01 after:2024-04-01`, viewer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := repository.MessageSearch{
+		Text:        `budget "q3 plan"`,
+		ChannelName: "finance",
+		SenderID:    viewer,
+		Before:      time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		After:       time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC),
+	}
+	if q != want {
+		t.Fatalf("got %+v\nwant %+v", q, want)
+	}
+}
+
+func TestParseSearchQuery_IDsAndQuotedSender(t *testing.T) {
+	chID, userID := uuid.New(), uuid.New()
+
+	q, err := parseSearchQuery("in:"+chID.String()+" from:"+userID.String(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.ChannelID != chID || q.SenderID != userID || q.Text != "" {
+		t.Fatalf("unexpected parse: %+v", q)
+	}
+
+	q, err = parseSearchQuery(`from:"Ana Lima" launch`, uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Sender != "Ana Lima" || q.Text != "launch" {
+		t.Fatalf("unexpected parse: %+v", q)
+	}
+}
+
+func TestParseSearchQuery_UnknownOperatorIsText(t *testing.T) {
+	q, err := parseSearchQuery("ratio:2 in:", uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Text != "ratio:2 in:" {
+		t.Fatalf("expected operators to fall back to text, got %+v", q)
+	}
+}
+
+func TestParseSearchQuery_InvalidDate(t *testing.T) {
+	if _, err := parseSearchQuery("before:yesterday", uuid.New()); err != ErrInvalidSearchDate {
+		t.Fatalf("expected ErrInvalidSearchDate, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Package storage holds uploaded file contents. Metadata (name, size,
+// owner, channel) lives in Postgres; a BlobStore only maps keys to bytes.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Get when no blob exists for the key.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore is a flat key → bytes store. Keys are slash-separated
+// ("<tenant>/<file>") so they map directly onto S3-style object keys.
+type BlobStore interface {
+	// Put stores r under key, replacing any existing blob. Either the
+	// whole blob is stored or nothing is.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// Get opens the blob for reading. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -3,10 +3,12 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	gorillaws "github.com/gorilla/websocket"
+	"github.com/lalith-99/echostream/internal/models"
 	"go.uber.org/zap"
 )
 
@@ -14,14 +16,34 @@ const (
 	writeWait      = 10 * time.Second
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10 // 54s
-	maxMessageSize = 4096
+	maxMessageSize = 8192                // a max-size "send" body (4000 bytes, escaped) plus envelope
 	sendBufSize    = 256
+
+	replayPageSize    = 100
+	maxReplayMessages = 500 // beyond this the client should page via HTTP ?after=
 )
 
 // MembershipChecker verifies whether a user belongs to a channel.
 // Injected from the api layer so the websocket package doesn't import repository.
 type MembershipChecker func(ctx context.Context, channelID, userID uuid.UUID) (bool, error)
 
+// MessageFetcher returns top-level messages with id > afterID, oldest first.
+// Used to replay history a reconnecting client missed.
+type MessageFetcher func(ctx context.Context, tenantID, channelID, userID uuid.UUID, afterID int64, limit int) ([]models.Message, error)
+
+// MessageSender persists and publishes a message on behalf of a client.
+// Business-rule failures should be returned as *SendError so the client
+// gets a typed error; anything else is reported as "internal".
+type MessageSender func(ctx context.Context, tenantID, channelID, senderID uuid.UUID, body string, parentID int64, clientMsgID string) (*models.Message, error)
+
+// SendError is a rejected send with a machine-readable code.
+type SendError struct {
+	Code    string
+	Message string
+}
+
+func (e *SendError) Error() string { return e.Message }
+
 type Client struct {
 	hub             *Hub
 	conn            *gorillaws.Conn
@@ -29,11 +51,21 @@ type Client struct {
 	userID          uuid.UUID
 	tenantID        uuid.UUID
 	checkMembership MembershipChecker // nil = skip check (backwards compat for tests)
+	fetchMessages   MessageFetcher    // nil = last_seen_id is ignored
+	sendMessage     MessageSender     // nil = "send" is rejected
 	logger          *zap.Logger
 }
 
 // NewClient creates a websocket client bound to a hub.
-func NewClient(hub *Hub, conn *gorillaws.Conn, userID, tenantID uuid.UUID, checker MembershipChecker, logger *zap.Logger) *Client {
+func NewClient(
+	hub *Hub,
+	conn *gorillaws.Conn,
+	userID, tenantID uuid.UUID,
+	checker MembershipChecker,
+	fetcher MessageFetcher,
+	sender MessageSender,
+	logger *zap.Logger,
+) *Client {
 	return &Client{
 		hub:             hub,
 		conn:            conn,
@@ -41,6 +73,8 @@ func NewClient(hub *Hub, conn *gorillaws.Conn, userID, tenantID uuid.UUID, check
 		userID:          userID,
 		tenantID:        tenantID,
 		checkMembership: checker,
+		fetchMessages:   fetcher,
+		sendMessage:     sender,
 		logger:          logger,
 	}
 }
@@ -135,7 +169,15 @@ func (c *Client) handleMessage(msg InboundMessage) {
 				return
 			}
 		}
-		c.hub.subscribeCh <- &subscription{client: c, channelID: channelID}
+		if msg.LastSeenID <= 0 || c.fetchMessages == nil {
+			c.hub.subscribeCh <- &subscription{client: c, channelID: channelID}
+			return
+		}
+		// Hold live events while we replay, so nothing published during
+		// the replay is lost or arrives ahead of older history.
+		c.hub.subscribeCh <- &subscription{client: c, channelID: channelID, hold: true}
+		lastID := c.replay(channelID, msg.LastSeenID)
+		c.hub.releaseCh <- &release{client: c, channelID: channelID, lastID: lastID}
 
 	case "unsubscribe":
 		channelID, err := uuid.Parse(msg.ChannelID)
@@ -153,11 +195,115 @@ func (c *Client) handleMessage(msg InboundMessage) {
 		}
 		c.hub.typingCh <- &typingEvent{channelID: channelID, userID: c.userID}
 
+	case "send":
+		c.handleSend(msg)
+
 	default:
 		c.sendError("unknown message type: " + msg.Type)
 	}
 }
 
+// handleSend posts a message and answers with an ack carrying the stored
+// message ID, or an error with a code, both tagged with the request_id.
+// The message itself still reaches subscribers through the normal
+// publish path, including this connection if it is subscribed.
+func (c *Client) handleSend(msg InboundMessage) {
+	if c.sendMessage == nil {
+		c.sendSendError(msg.RequestID, "unsupported", "send is not enabled")
+		return
+	}
+	channelID, err := uuid.Parse(msg.ChannelID)
+	if err != nil {
+		c.sendSendError(msg.RequestID, "invalid_channel", "invalid channel_id")
+		return
+	}
+
+	stored, err := c.sendMessage(context.Background(), c.tenantID, channelID, c.userID, msg.Body, msg.ParentID, msg.ClientMsgID)
+	if err != nil {
+		var sendErr *SendError
+		if errors.As(err, &sendErr) {
+			c.sendSendError(msg.RequestID, sendErr.Code, sendErr.Message)
+			return
+		}
+		c.logger.Error("ws send failed", zap.Error(err))
+		c.sendSendError(msg.RequestID, "internal", "failed to send message")
+		return
+	}
+
+	data, err := json.Marshal(OutboundEvent{
+		Type:      "ack",
+		RequestID: msg.RequestID,
+		ChannelID: channelID.String(),
+		MessageID: stored.ID,
+	})
+	if err != nil {
+		c.logger.Error("failed to marshal ack", zap.Error(err))
+		return
+	}
+	c.Send(data)
+}
+
+func (c *Client) sendSendError(requestID, code, msg string) {
+	data, err := json.Marshal(OutboundEvent{Type: "error", RequestID: requestID, Code: code, Error: msg})
+	if err != nil {
+		c.logger.Error("failed to marshal error event", zap.Error(err))
+		return
+	}
+	c.Send(data)
+}
+
+// replay streams messages after afterID as ordinary "message" events and
+// returns the last ID sent. It runs on the ReadPump goroutine after the
+// hub has started holding this channel's broadcasts, so any message
+// committed after the fetch is caught by the hold instead.
+func (c *Client) replay(channelID uuid.UUID, afterID int64) int64 {
+	lastID := afterID
+	for sent := 0; sent < maxReplayMessages; {
+		msgs, err := c.fetchMessages(context.Background(), c.tenantID, channelID, c.userID, lastID, replayPageSize)
+		if err != nil {
+			c.logger.Error("replay fetch failed", zap.Error(err))
+			c.sendError("failed to replay missed messages")
+			return lastID
+		}
+		for i := range msgs {
+			data, err := json.Marshal(OutboundEvent{Type: "message", ChannelID: channelID.String(), Message: &msgs[i]})
+			if err != nil {
+				c.logger.Error("failed to marshal replay event", zap.Error(err))
+				return lastID
+			}
+			if !c.sendBlocking(data) {
+				return lastID
+			}
+			lastID = msgs[i].ID
+		}
+		sent += len(msgs)
+		if len(msgs) < replayPageSize {
+			return lastID
+		}
+	}
+
+	// Too far behind: tell the client where replay stopped so it can
+	// page the rest with GET /messages?after=.
+	data, err := json.Marshal(OutboundEvent{Type: "replay_truncated", ChannelID: channelID.String(), MessageID: lastID})
+	if err == nil {
+		c.sendBlocking(data)
+	}
+	return lastID
+}
+
+// sendBlocking waits for room in the send buffer instead of dropping.
+// A replay can be larger than the buffer; WritePump drains it meanwhile.
+// Only safe from the ReadPump goroutine, which is the only path that
+// closes c.send (via unregister).
+func (c *Client) sendBlocking(data []byte) bool {
+	select {
+	case c.send <- data:
+		return true
+	case <-time.After(writeWait):
+		return false
+	}
+}
+
 func (c *Client) sendError(msg string) {
 	data, err := json.Marshal(OutboundEvent{Type: "error", Error: msg})
 	if err != nil {
@@ -12,6 +12,41 @@ import (
 type subscription struct {
 	client    *Client
 	channelID uuid.UUID
+	hold      bool // buffer live broadcasts until release (replay in progress)
+}
+
+// release ends a hold: buffered broadcasts are flushed, minus any
+// "message" events for IDs the replay already delivered (<= lastID).
+type release struct {
+	client    *Client
+	channelID uuid.UUID
+	lastID    int64
+}
+
+// heldEvents is what a hold has buffered. If more than sendBufSize events
+// arrive, the buffer is dropped and the release tells the client to page
+// the gap instead, so its timeline never silently skips events.
+type heldEvents struct {
+	events     [][]byte
+	overflowed bool
+}
+
+func (b *heldEvents) add(data []byte) {
+	switch {
+	case b.overflowed:
+	case len(b.events) >= sendBufSize:
+		b.events, b.overflowed = nil, true
+	default:
+		b.events = append(b.events, data)
+	}
+}
+
+// evict drops a channel's subscribers whose user is in users, or with
+// keep set, whose user is not in users.
+type evict struct {
+	channelID uuid.UUID
+	users     map[uuid.UUID]struct{}
+	keep      bool
 }
 
 type typingEvent struct {
@@ -24,6 +59,16 @@ type broadcastMessage struct {
 	data      []byte
 }
 
+type userMessage struct {
+	userID uuid.UUID
+	data   []byte
+}
+
+type tenantMessage struct {
+	tenantID uuid.UUID
+	data     []byte
+}
+
 // Hub maintains active clients and routes messages between them.
 // All state is managed in the Run goroutine — no locks needed.
 type Hub struct {
@@ -31,13 +76,20 @@ type Hub struct {
 	channels map[uuid.UUID]map[*Client]struct{}
 	// client → set of channels they're in
 	clientChannels map[*Client]map[uuid.UUID]struct{}
+	// client → channel → broadcasts held back while the client replays history
+	held map[*Client]map[uuid.UUID]*heldEvents
 
 	register      chan *Client
 	unregister    chan *Client
 	subscribeCh   chan *subscription
 	unsubscribeCh chan *subscription
+	releaseCh     chan *release
+	evictCh       chan *evict
 	broadcastCh   chan *broadcastMessage
+	userCh        chan *userMessage
+	tenantCh      chan *tenantMessage
 	typingCh      chan *typingEvent
+	resyncCh      chan struct{}
 	shutdown      chan struct{}
 
 	// Called when a channel gets its first local subscriber.
@@ -45,9 +97,20 @@ type Hub struct {
 	// Called when a channel loses its last local subscriber.
 	onChannelInactive func(channelID uuid.UUID)
 
-	presence  *presence.Tracker              // nil until SetPresenceTracker is called
-	userConns map[uuid.UUID]int              // open WS conns per userID
-	cancelKA  map[*Client]context.CancelFunc // per-client keepalive cancel
+	// Called when a user opens their first local connection.
+	onUserActive func(userID uuid.UUID)
+	// Called when a user closes their last local connection.
+	onUserInactive func(userID uuid.UUID)
+
+	// Called when a tenant gets its first local connection.
+	onTenantActive func(tenantID uuid.UUID)
+	// Called when a tenant loses its last local connection.
+	onTenantInactive func(tenantID uuid.UUID)
+
+	presence *presence.Tracker                  // nil until SetPresenceTracker is called
+	users    map[uuid.UUID]map[*Client]struct{} // open WS conns per userID
+	tenants  map[uuid.UUID]map[*Client]struct{} // open WS conns per tenantID
+	cancelKA map[*Client]context.CancelFunc     // per-client keepalive cancel
 
 	logger *zap.Logger
 }
@@ -57,14 +120,21 @@ func NewHub(logger *zap.Logger) *Hub {
 	return &Hub{
 		channels:       make(map[uuid.UUID]map[*Client]struct{}),
 		clientChannels: make(map[*Client]map[uuid.UUID]struct{}),
+		held:           make(map[*Client]map[uuid.UUID]*heldEvents),
 		register:       make(chan *Client),
 		unregister:     make(chan *Client),
 		subscribeCh:    make(chan *subscription),
 		unsubscribeCh:  make(chan *subscription),
+		releaseCh:      make(chan *release),
+		evictCh:        make(chan *evict, 64),
 		broadcastCh:    make(chan *broadcastMessage, 256),
+		userCh:         make(chan *userMessage, 256),
+		tenantCh:       make(chan *tenantMessage, 256),
 		typingCh:       make(chan *typingEvent, 256),
+		resyncCh:       make(chan struct{}, 1),
 		shutdown:       make(chan struct{}),
-		userConns:      make(map[uuid.UUID]int),
+		users:          make(map[uuid.UUID]map[*Client]struct{}),
+		tenants:        make(map[uuid.UUID]map[*Client]struct{}),
 		cancelKA:       make(map[*Client]context.CancelFunc),
 		logger:         logger,
 	}
@@ -76,6 +146,18 @@ func (h *Hub) SetChannelCallbacks(onActive, onInactive func(uuid.UUID)) {
 	h.onChannelInactive = onInactive
 }
 
+// SetUserCallbacks wires per-user event streams to Redis pub/sub.
+func (h *Hub) SetUserCallbacks(onActive, onInactive func(uuid.UUID)) {
+	h.onUserActive = onActive
+	h.onUserInactive = onInactive
+}
+
+// SetTenantCallbacks wires tenant-wide event streams to Redis pub/sub.
+func (h *Hub) SetTenantCallbacks(onActive, onInactive func(uuid.UUID)) {
+	h.onTenantActive = onActive
+	h.onTenantInactive = onInactive
+}
+
 // SetPresenceTracker enables online/offline tracking via Redis.
 func (h *Hub) SetPresenceTracker(t *presence.Tracker) {
 	h.presence = t
@@ -92,6 +174,52 @@ func (h *Hub) Broadcast(channelID uuid.UUID, data []byte) {
 	h.broadcastCh <- &broadcastMessage{channelID: channelID, data: data}
 }
 
+// BroadcastToUser sends data to every local connection of a user,
+// regardless of which channels they're subscribed to.
+// Safe to call from any goroutine (e.g., the Redis listener).
+func (h *Hub) BroadcastToUser(userID uuid.UUID, data []byte) {
+	h.userCh <- &userMessage{userID: userID, data: data}
+}
+
+// BroadcastToTenant sends data to every local connection in a tenant.
+// Safe to call from any goroutine (e.g., the Redis listener).
+func (h *Hub) BroadcastToTenant(tenantID uuid.UUID, data []byte) {
+	h.tenantCh <- &tenantMessage{tenantID: tenantID, data: data}
+}
+
+// RetainSubscribers unsubscribes every local client in the channel whose
+// user is not in userIDs, e.g. after the channel was made private. Evicted
+// clients get an "unsubscribed" event. Safe to call from any goroutine.
+func (h *Hub) RetainSubscribers(channelID uuid.UUID, userIDs []uuid.UUID) {
+	h.evictCh <- &evict{channelID: channelID, users: userSet(userIDs), keep: true}
+}
+
+// EvictSubscribers unsubscribes every local client of the given users from
+// the channel, e.g. after they left or were removed. Evicted clients get an
+// "unsubscribed" event. Safe to call from any goroutine.
+func (h *Hub) EvictSubscribers(channelID uuid.UUID, userIDs []uuid.UUID) {
+	h.evictCh <- &evict{channelID: channelID, users: userSet(userIDs)}
+}
+
+// Resync tells every local client that events may have been lost, e.g.
+// when the Redis event stream was trimmed before this node read it.
+// Clients should resubscribe with last_seen_id. Never blocks; requests
+// made while one is pending are merged. Safe to call from any goroutine.
+func (h *Hub) Resync() {
+	select {
+	case h.resyncCh <- struct{}{}:
+	default:
+	}
+}
+
+func userSet(userIDs []uuid.UUID) map[uuid.UUID]struct{} {
+	set := make(map[uuid.UUID]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
 // Shutdown signals the hub to stop processing events.
 func (h *Hub) Shutdown() {
 	close(h.shutdown)
@@ -106,7 +234,20 @@ func (h *Hub) Run() {
 			return
 		case client := <-h.register:
 			h.clientChannels[client] = make(map[uuid.UUID]struct{})
-			h.userConns[client.userID]++
+			if _, ok := h.users[client.userID]; !ok {
+				h.users[client.userID] = make(map[*Client]struct{})
+				if h.onUserActive != nil {
+					h.onUserActive(client.userID)
+				}
+			}
+			h.users[client.userID][client] = struct{}{}
+			if _, ok := h.tenants[client.tenantID]; !ok {
+				h.tenants[client.tenantID] = make(map[*Client]struct{})
+				if h.onTenantActive != nil {
+					h.onTenantActive(client.tenantID)
+				}
+			}
+			h.tenants[client.tenantID][client] = struct{}{}
 
 			// Start presence tracking for this connection
 			if h.presence != nil {
@@ -126,6 +267,7 @@ func (h *Hub) Run() {
 					h.removeFromChannel(client, chID)
 				}
 				delete(h.clientChannels, client)
+				delete(h.held, client)
 				close(client.send)
 			}
 
@@ -136,11 +278,25 @@ func (h *Hub) Run() {
 			}
 
 			// Only set offline when last connection for this user closes
-			h.userConns[client.userID]--
-			if h.userConns[client.userID] <= 0 {
-				delete(h.userConns, client.userID)
-				if h.presence != nil {
-					h.presence.SetOffline(context.Background(), client.userID)
+			if conns, ok := h.users[client.userID]; ok {
+				delete(conns, client)
+				if len(conns) == 0 {
+					delete(h.users, client.userID)
+					if h.onUserInactive != nil {
+						h.onUserInactive(client.userID)
+					}
+					if h.presence != nil {
+						h.presence.SetOffline(context.Background(), client.userID)
+					}
+				}
+			}
+			if conns, ok := h.tenants[client.tenantID]; ok {
+				delete(conns, client)
+				if len(conns) == 0 {
+					delete(h.tenants, client.tenantID)
+					if h.onTenantInactive != nil {
+						h.onTenantInactive(client.tenantID)
+					}
 				}
 			}
 
@@ -150,6 +306,12 @@ func (h *Hub) Run() {
 
 		case sub := <-h.subscribeCh:
 			h.addToChannel(sub.client, sub.channelID)
+			if sub.hold {
+				if h.held[sub.client] == nil {
+					h.held[sub.client] = make(map[uuid.UUID]*heldEvents)
+				}
+				h.held[sub.client][sub.channelID] = &heldEvents{}
+			}
 			data, err := json.Marshal(OutboundEvent{Type: "subscribed", ChannelID: sub.channelID.String()})
 			if err != nil {
 				h.logger.Error("failed to marshal subscribed event", zap.Error(err))
@@ -172,13 +334,43 @@ func (h *Hub) Run() {
 				sub.client.Send(data)
 			}
 
+		case rel := <-h.releaseCh:
+			h.releaseHold(rel)
+
+		case ev := <-h.evictCh:
+			h.evictSubscribers(ev)
+
+		case <-h.resyncCh:
+			data, err := json.Marshal(OutboundEvent{Type: "resync"})
+			if err != nil {
+				h.logger.Error("failed to marshal resync event", zap.Error(err))
+				continue
+			}
+			for client := range h.clientChannels {
+				client.Send(data)
+			}
+
 		case msg := <-h.broadcastCh:
 			if clients, ok := h.channels[msg.channelID]; ok {
 				for client := range clients {
+					if held, holding := h.held[client][msg.channelID]; holding {
+						held.add(msg.data)
+						continue
+					}
 					client.Send(msg.data)
 				}
 			}
 
+		case msg := <-h.userCh:
+			for client := range h.users[msg.userID] {
+				client.Send(msg.data)
+			}
+
+		case msg := <-h.tenantCh:
+			for client := range h.tenants[msg.tenantID] {
+				client.Send(msg.data)
+			}
+
 		case ev := <-h.typingCh:
 			if clients, ok := h.channels[ev.channelID]; ok {
 				event := OutboundEvent{
@@ -237,6 +429,71 @@ func (h *Hub) removeFromChannel(client *Client, channelID uuid.UUID) {
 	if channels, ok := h.clientChannels[client]; ok {
 		delete(channels, channelID)
 	}
+	if holds, ok := h.held[client]; ok {
+		delete(holds, channelID)
+	}
+}
+
+func (h *Hub) evictSubscribers(ev *evict) {
+	data, err := json.Marshal(OutboundEvent{Type: "unsubscribed", ChannelID: ev.channelID.String()})
+	if err != nil {
+		h.logger.Error("failed to marshal unsubscribed event", zap.Error(err))
+		return
+	}
+	for client := range h.channels[ev.channelID] {
+		if _, listed := ev.users[client.userID]; listed == ev.keep {
+			continue
+		}
+		h.removeFromChannel(client, ev.channelID)
+		client.Send(data)
+		h.logger.Debug("evicted subscriber",
+			zap.String("user_id", client.userID.String()),
+			zap.String("channel_id", ev.channelID.String()),
+		)
+	}
+}
+
+// releaseHold flushes broadcasts buffered during a replay. Messages the
+// replay already streamed are dropped so the client sees each one once.
+// If the buffer overflowed, the client gets replay_truncated at the last
+// replayed ID instead and pages the rest with GET /messages?after=.
+func (h *Hub) releaseHold(rel *release) {
+	holds, ok := h.held[rel.client]
+	if !ok {
+		return
+	}
+	held, ok := holds[rel.channelID]
+	if !ok {
+		return
+	}
+	delete(holds, rel.channelID)
+	if len(holds) == 0 {
+		delete(h.held, rel.client)
+	}
+
+	if held.overflowed {
+		data, err := json.Marshal(OutboundEvent{Type: "replay_truncated", ChannelID: rel.channelID.String(), MessageID: rel.lastID})
+		if err != nil {
+			h.logger.Error("failed to marshal replay_truncated event", zap.Error(err))
+			return
+		}
+		rel.client.Send(data)
+		return
+	}
+
+	for _, data := range held.events {
+		var ev struct {
+			Type    string `json:"type"`
+			Message struct {
+				ID int64 `json:"id"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(data, &ev); err == nil &&
+			ev.Type == "message" && ev.Message.ID != 0 && ev.Message.ID <= rel.lastID {
+			continue
+		}
+		rel.client.Send(data)
+	}
 }
 
 // broadcastPresence sends a presence_change event to all subscribers of a
@@ -1,11 +1,13 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lalith-99/echostream/internal/models"
 	"go.uber.org/zap"
 )
 
@@ -163,6 +165,183 @@ func TestChannelCallbacks(t *testing.T) {
 	}
 }
 
+func TestBroadcastToUser(t *testing.T) {
+	hub := startHub(t)
+	userID := uuid.New()
+	phone := fakeClient(hub, userID)
+	laptop := fakeClient(hub, userID)
+	other := fakeClient(hub, uuid.New())
+
+	hub.register <- phone
+	hub.register <- laptop
+	hub.register <- other
+	time.Sleep(50 * time.Millisecond)
+
+	data, _ := json.Marshal(OutboundEvent{Type: "read_marker", MessageID: 9})
+	hub.BroadcastToUser(userID, data)
+	time.Sleep(50 * time.Millisecond)
+
+	// Both of the user's connections get it, with no channel subscription.
+	for _, c := range []*Client{phone, laptop} {
+		if ev := drainOne(t, c); ev.Type != "read_marker" || ev.MessageID != 9 {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	}
+	select {
+	case <-other.send:
+		t.Fatal("other user should not receive the event")
+	default:
+	}
+}
+
+func TestUserCallbacks(t *testing.T) {
+	hub := startHub(t)
+
+	var activated, deactivated int
+	hub.SetUserCallbacks(
+		func(uuid.UUID) { activated++ },
+		func(uuid.UUID) { deactivated++ },
+	)
+
+	userID := uuid.New()
+	c1 := fakeClient(hub, userID)
+	c2 := fakeClient(hub, userID)
+
+	hub.register <- c1
+	hub.register <- c2
+	time.Sleep(50 * time.Millisecond)
+	if activated != 1 {
+		t.Fatalf("expected onUserActive once for two connections, got %d", activated)
+	}
+
+	hub.unregister <- c1
+	time.Sleep(50 * time.Millisecond)
+	if deactivated != 0 {
+		t.Fatal("onUserInactive fired while a connection is still open")
+	}
+
+	hub.unregister <- c2
+	time.Sleep(50 * time.Millisecond)
+	if deactivated != 1 {
+		t.Fatalf("expected onUserInactive after last connection, got %d", deactivated)
+	}
+}
+
+func TestReplayHoldsAndDedupesLiveMessages(t *testing.T) {
+	hub := startHub(t)
+	chID := uuid.New()
+	c := fakeClient(hub, uuid.New())
+	c.fetchMessages = func(_ context.Context, _, _, _ uuid.UUID, afterID int64, _ int) ([]models.Message, error) {
+		// A message published while the replay is running: it is also in
+		// the DB result, so the held copy must be dropped.
+		live, _ := json.Marshal(OutboundEvent{Type: "message", ChannelID: chID.String(), Message: models.Message{ID: 6}})
+		hub.Broadcast(chID, live)
+		later, _ := json.Marshal(OutboundEvent{Type: "message", ChannelID: chID.String(), Message: models.Message{ID: 7}})
+		hub.Broadcast(chID, later)
+		time.Sleep(50 * time.Millisecond)
+		if afterID != 4 {
+			return nil, nil
+		}
+		return []models.Message{{ID: 5}, {ID: 6}}, nil
+	}
+
+	hub.register <- c
+	time.Sleep(50 * time.Millisecond)
+
+	c.handleMessage(InboundMessage{Type: "subscribe", ChannelID: chID.String(), LastSeenID: 4})
+	time.Sleep(50 * time.Millisecond)
+
+	if ev := drainOne(t, c); ev.Type != "subscribed" {
+		t.Fatalf("expected subscribed, got %s", ev.Type)
+	}
+	var got []int64
+	for i := 0; i < 3; i++ {
+		ev := drainOne(t, c)
+		m, _ := json.Marshal(ev.Message)
+		var msg models.Message
+		_ = json.Unmarshal(m, &msg)
+		got = append(got, msg.ID)
+	}
+	if got[0] != 5 || got[1] != 6 || got[2] != 7 {
+		t.Fatalf("expected messages 5, 6, 7 in order, got %v", got)
+	}
+	select {
+	case data := <-c.send:
+		t.Fatalf("unexpected extra event: %s", data)
+	default:
+	}
+}
+
+func TestReplayHoldOverflowSendsTruncated(t *testing.T) {
+	hub := startHub(t)
+	chID := uuid.New()
+	c := fakeClient(hub, uuid.New())
+	c.fetchMessages = func(_ context.Context, _, _, _ uuid.UUID, afterID int64, _ int) ([]models.Message, error) {
+		if afterID != 4 {
+			return nil, nil
+		}
+		// More live traffic during the replay than a hold can keep.
+		for i := 0; i <= sendBufSize; i++ {
+			live, _ := json.Marshal(OutboundEvent{Type: "message", ChannelID: chID.String(), Message: models.Message{ID: int64(100 + i)}})
+			hub.Broadcast(chID, live)
+		}
+		time.Sleep(50 * time.Millisecond)
+		return []models.Message{{ID: 5}}, nil
+	}
+
+	hub.register <- c
+	time.Sleep(50 * time.Millisecond)
+
+	c.handleMessage(InboundMessage{Type: "subscribe", ChannelID: chID.String(), LastSeenID: 4})
+	time.Sleep(50 * time.Millisecond)
+
+	if ev := drainOne(t, c); ev.Type != "subscribed" {
+		t.Fatalf("expected subscribed, got %s", ev.Type)
+	}
+	if ev := drainOne(t, c); ev.Type != "message" {
+		t.Fatalf("expected the replayed message, got %s", ev.Type)
+	}
+	ev := drainOne(t, c)
+	if ev.Type != "replay_truncated" || ev.MessageID != 5 {
+		t.Fatalf("expected replay_truncated at 5, got %+v", ev)
+	}
+	select {
+	case data := <-c.send:
+		t.Fatalf("held events should have been dropped, got %s", data)
+	default:
+	}
+}
+
+func TestSendAcksWithMessageID(t *testing.T) {
+	hub := startHub(t)
+	c := fakeClient(hub, uuid.New())
+	c.sendMessage = func(_ context.Context, _, _, _ uuid.UUID, body string, _ int64, _ string) (*models.Message, error) {
+		return &models.Message{ID: 42, Body: body}, nil
+	}
+
+	c.handleMessage(InboundMessage{Type: "send", RequestID: "r1", ChannelID: uuid.NewString(), Body: "hi"})
+
+	ev := drainOne(t, c)
+	if ev.Type != "ack" || ev.RequestID != "r1" || ev.MessageID != 42 {
+		t.Fatalf("unexpected ack: %+v", ev)
+	}
+}
+
+func TestSendReturnsTypedError(t *testing.T) {
+	hub := startHub(t)
+	c := fakeClient(hub, uuid.New())
+	c.sendMessage = func(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, string, int64, string) (*models.Message, error) {
+		return nil, &SendError{Code: "not_member", Message: "sender is not a member of this channel"}
+	}
+
+	c.handleMessage(InboundMessage{Type: "send", RequestID: "r2", ChannelID: uuid.NewString(), Body: "hi"})
+
+	ev := drainOne(t, c)
+	if ev.Type != "error" || ev.RequestID != "r2" || ev.Code != "not_member" {
+		t.Fatalf("unexpected error event: %+v", ev)
+	}
+}
+
 func TestTypingExcludesSender(t *testing.T) {
 	hub := startHub(t)
 	sender := fakeClient(hub, uuid.New())
@@ -178,6 +357,7 @@ func TestTypingExcludesSender(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 	_ = drainOne(t, sender)   // ack
 	_ = drainOne(t, receiver) // ack
+	_ = drainOne(t, sender)   // presence_change online for receiver
 
 	// Send typing event
 	hub.typingCh <- &typingEvent{channelID: chID, userID: sender.userID}
@@ -325,3 +505,154 @@ func TestPresenceBroadcastOnDisconnect(t *testing.T) {
 		t.Fatalf("expected user_id=%s, got %s", bob.userID, ev.UserID)
 	}
 }
+
+func TestRetainSubscribers_EvictsNonMembers(t *testing.T) {
+	hub := startHub(t)
+	member := fakeClient(hub, uuid.New())
+	outsider := fakeClient(hub, uuid.New())
+	chID := uuid.New()
+
+	for _, c := range []*Client{member, outsider} {
+		hub.register <- c
+		hub.subscribeCh <- &subscription{client: c, channelID: chID}
+	}
+	time.Sleep(50 * time.Millisecond)
+	drainOne(t, member)   // subscribed
+	drainOne(t, member)   // outsider's presence_change
+	drainOne(t, outsider) // subscribed
+
+	hub.RetainSubscribers(chID, []uuid.UUID{member.userID})
+	time.Sleep(50 * time.Millisecond)
+
+	if ev := drainOne(t, outsider); ev.Type != "unsubscribed" || ev.ChannelID != chID.String() {
+		t.Fatalf("expected unsubscribed for %s, got %+v", chID, ev)
+	}
+	// The member sees the outsider go offline, and nothing else.
+	if ev := drainOne(t, member); ev.Type != "presence_change" || ev.Status != "offline" {
+		t.Fatalf("expected offline presence, got %+v", ev)
+	}
+
+	hub.Broadcast(chID, []byte(`{"type":"message"}`))
+	time.Sleep(50 * time.Millisecond)
+
+	if ev := drainOne(t, member); ev.Type != "message" {
+		t.Fatalf("member should still receive broadcasts, got %+v", ev)
+	}
+	select {
+	case data := <-outsider.send:
+		t.Fatalf("evicted client got %s", data)
+	default:
+	}
+}
+
+func TestEvictSubscribers_DropsAllOfUsersClients(t *testing.T) {
+	hub := startHub(t)
+	leaverID := uuid.New()
+	phone := fakeClient(hub, leaverID)
+	laptop := fakeClient(hub, leaverID)
+	stayer := fakeClient(hub, uuid.New())
+	chID := uuid.New()
+
+	for _, c := range []*Client{stayer, phone, laptop} {
+		hub.register <- c
+		hub.subscribeCh <- &subscription{client: c, channelID: chID}
+	}
+	time.Sleep(50 * time.Millisecond)
+	drainOne(t, stayer) // subscribed
+	drainOne(t, stayer) // phone's presence_change
+	drainOne(t, stayer) // laptop's presence_change
+	drainOne(t, phone)  // subscribed
+	drainOne(t, laptop) // subscribed
+
+	hub.EvictSubscribers(chID, []uuid.UUID{leaverID})
+	time.Sleep(50 * time.Millisecond)
+
+	for _, c := range []*Client{phone, laptop} {
+		if ev := drainOne(t, c); ev.Type != "unsubscribed" || ev.ChannelID != chID.String() {
+			t.Fatalf("expected unsubscribed for %s, got %+v", chID, ev)
+		}
+	}
+
+	hub.Broadcast(chID, []byte(`{"type":"message"}`))
+	time.Sleep(50 * time.Millisecond)
+
+	if ev := drainOne(t, stayer); ev.Type != "presence_change" || ev.Status != "offline" {
+		t.Fatalf("expected the leaver to go offline, got %+v", ev)
+	}
+	if ev := drainOne(t, stayer); ev.Type != "message" {
+		t.Fatalf("remaining subscriber should still receive broadcasts, got %+v", ev)
+	}
+	for _, c := range []*Client{phone, laptop} {
+		select {
+		case data := <-c.send:
+			t.Fatalf("evicted client got %s", data)
+		default:
+		}
+	}
+}
+
+func TestBroadcastToTenant(t *testing.T) {
+	hub := startHub(t)
+	active := make(chan uuid.UUID, 4)
+	inactive := make(chan uuid.UUID, 4)
+	hub.SetTenantCallbacks(
+		func(id uuid.UUID) { active <- id },
+		func(id uuid.UUID) { inactive <- id },
+	)
+
+	alice := fakeClient(hub, uuid.New())
+	bob := fakeClient(hub, uuid.New())
+	bob.tenantID = alice.tenantID
+	outsider := fakeClient(hub, uuid.New())
+
+	for _, c := range []*Client{alice, bob, outsider} {
+		hub.register <- c
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(active) != 2 {
+		t.Fatalf("expected onTenantActive once per tenant, got %d", len(active))
+	}
+
+	data, _ := json.Marshal(OutboundEvent{Type: "channel_created"})
+	hub.BroadcastToTenant(alice.tenantID, data)
+	time.Sleep(50 * time.Millisecond)
+
+	// Everyone in the tenant gets it, with no channel subscription.
+	for _, c := range []*Client{alice, bob} {
+		if ev := drainOne(t, c); ev.Type != "channel_created" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	}
+	select {
+	case <-outsider.send:
+		t.Fatal("other tenant should not receive the event")
+	default:
+	}
+
+	hub.unregister <- alice
+	time.Sleep(50 * time.Millisecond)
+	if len(inactive) != 0 {
+		t.Fatal("onTenantInactive fired while a connection is still open")
+	}
+	hub.unregister <- bob
+	time.Sleep(50 * time.Millisecond)
+	if len(inactive) != 1 || <-inactive != alice.tenantID {
+		t.Fatal("expected onTenantInactive after the tenant's last connection")
+	}
+}
+
+func TestResyncReachesEveryClient(t *testing.T) {
+	hub := startHub(t)
+	c1 := fakeClient(hub, uuid.New())
+	c2 := fakeClient(hub, uuid.New())
+	hub.register <- c1
+	hub.register <- c2
+
+	hub.Resync()
+
+	for _, c := range []*Client{c1, c2} {
+		if ev := drainOne(t, c); ev.Type != "resync" {
+			t.Fatalf("expected resync, got %s", ev.Type)
+		}
+	}
+}
@@ -2,17 +2,30 @@ package websocket
 
 // InboundMessage is sent from the client over WebSocket.
 type InboundMessage struct {
-	Type      string `json:"type"` // subscribe, unsubscribe, typing
-	ChannelID string `json:"channel_id,omitempty"`
-	Body      string `json:"body,omitempty"`
+	Type       string `json:"type"` // subscribe, unsubscribe, typing, send
+	ChannelID  string `json:"channel_id,omitempty"`
+	Body       string `json:"body,omitempty"`
+	LastSeenID int64  `json:"last_seen_id,omitempty"` // subscribe: replay messages after this ID first
+
+	// send only
+	RequestID   string `json:"request_id,omitempty"` // echoed in the ack or error
+	ParentID    int64  `json:"parent_id,omitempty"`
+	ClientMsgID string `json:"client_msg_id,omitempty"`
 }
 
 // OutboundEvent is sent from the server to the client over WebSocket.
 type OutboundEvent struct {
-	Type      string `json:"type"` // message, typing, subscribed, unsubscribed, presence_change, error
-	ChannelID string `json:"channel_id,omitempty"`
-	Message   any    `json:"message,omitempty"`
-	UserID    string `json:"user_id,omitempty"`
-	Status    string `json:"status,omitempty"` // "online" or "offline" (presence_change events)
-	Error     string `json:"error,omitempty"`
+	Type         string `json:"type"` // message, message_updated, message_deleted, thread_reply, reaction_added, reaction_removed, pin_added, pin_removed, read_marker, mention, scheduled_message_failed, channel_created, channel_updated, channel_archived, channel_hidden, added_to_channel, member_joined, member_left, member_invited, removed_from_channel, typing, subscribed, replay_truncated, resync, unsubscribed, presence_change, ack, error
+	ChannelID    string `json:"channel_id,omitempty"`
+	Message      any    `json:"message,omitempty"`
+	Channel      any    `json:"channel,omitempty"`        // channel lifecycle and added_to_channel events
+	MessageID    int64  `json:"message_id,omitempty"`     // reaction, pin, read_marker, replay_truncated and ack events
+	Emoji        string `json:"emoji,omitempty"`          // reaction events
+	UserID       string `json:"user_id,omitempty"`        // member events and removed_from_channel: who did it
+	TargetUserID string `json:"target_user_id,omitempty"` // member events: who joined, left or was invited
+	Status       string `json:"status,omitempty"`         // "online" or "offline" (presence_change events)
+	MentionKind  string `json:"mention_kind,omitempty"`   // user, here or channel (mention events)
+	Error        string `json:"error,omitempty"`
+	Code         string `json:"code,omitempty"`       // machine-readable error kind (send errors)
+	RequestID    string `json:"request_id,omitempty"` // ack and send errors
 }
@@ -0,0 +1,16 @@
+-- Message editing: track when a message was last edited and keep every
+-- prior body so edits are auditable.
+
+ALTER TABLE messages ADD COLUMN edited_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS message_edits (
+  id bigserial PRIMARY KEY,
+  message_id bigint NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+  editor_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  previous_body text NOT NULL,
+  edited_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- History is always read per message, oldest edit first.
+CREATE INDEX IF NOT EXISTS idx_message_edits_message_id
+  ON message_edits (message_id, id);
@@ -0,0 +1,5 @@
+-- Revert: drop tombstone columns (tombstoned rows become visible again,
+-- with an empty body).
+
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- Soft delete for messages. Rows are tombstoned instead of removed so
+-- id-based cursor pagination keeps working and clients can render
+-- "message deleted" placeholders.
+
+ALTER TABLE messages ADD COLUMN deleted_at timestamptz;
+ALTER TABLE messages ADD COLUMN deleted_by uuid REFERENCES users(id) ON DELETE SET NULL;
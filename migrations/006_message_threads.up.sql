@@ -0,0 +1,12 @@
+-- Threaded replies. A reply points at its top-level parent; the parent
+-- keeps denormalized counters so channel history can show "3 replies"
+-- without a second query.
+
+ALTER TABLE messages ADD COLUMN parent_id bigint REFERENCES messages(id) ON DELETE CASCADE;
+ALTER TABLE messages ADD COLUMN reply_count integer NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN last_reply_at timestamptz;
+
+-- Replies are paginated per parent by id, same as channel history.
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id
+  ON messages (parent_id, id DESC)
+  WHERE parent_id IS NOT NULL;
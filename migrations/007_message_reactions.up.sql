@@ -0,0 +1,10 @@
+-- Emoji reactions. One row per (message, user, emoji) so a user can add
+-- several different emoji to a message but each only once.
+
+CREATE TABLE IF NOT EXISTS message_reactions (
+  message_id bigint NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  emoji text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (message_id, user_id, emoji)
+);
@@ -0,0 +1,9 @@
+-- Full-text search over message bodies.
+-- A generated column keeps the tsvector in sync with body automatically
+-- (including edits and soft-deletes, which blank the body).
+
+ALTER TABLE messages ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_messages_search_vector
+  ON messages USING GIN (search_vector);
@@ -0,0 +1,6 @@
+-- Revert: DMs become ordinary private channels.
+
+DROP INDEX IF EXISTS idx_channels_tenant_dm_key;
+
+ALTER TABLE channels DROP COLUMN IF EXISTS dm_key;
+ALTER TABLE channels DROP COLUMN IF EXISTS kind;
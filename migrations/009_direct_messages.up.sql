@@ -0,0 +1,13 @@
+-- Direct messages are channels with a different kind, so messages,
+-- membership checks and websocket subscriptions work unchanged.
+--
+-- dm_key is the sorted, comma-joined participant IDs. The unique index
+-- makes "find or create" for a participant set race-free.
+
+ALTER TABLE channels ADD COLUMN kind text NOT NULL DEFAULT 'channel'
+  CHECK (kind IN ('channel', 'dm', 'group_dm'));
+ALTER TABLE channels ADD COLUMN dm_key text;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channels_tenant_dm_key
+  ON channels (tenant_id, dm_key)
+  WHERE dm_key IS NOT NULL;
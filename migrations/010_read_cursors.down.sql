@@ -0,0 +1 @@
+ALTER TABLE channel_members DROP COLUMN IF EXISTS last_read_id;
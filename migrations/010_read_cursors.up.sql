@@ -0,0 +1,10 @@
+-- Per-member read cursor. Everything in the channel with id > last_read_id
+-- is unread; counting walks idx_messages_channel_id (channel_id, id DESC).
+
+ALTER TABLE channel_members ADD COLUMN last_read_id bigint NOT NULL DEFAULT 0;
+
+-- Start existing members at the head of each channel so the migration
+-- doesn't flag the whole history as unread.
+UPDATE channel_members cm
+SET last_read_id = COALESCE(
+  (SELECT max(m.id) FROM messages m WHERE m.channel_id = cm.channel_id), 0);
@@ -0,0 +1,8 @@
+-- Client-generated ID for idempotent sends. A retried POST with the same
+-- key from the same sender in the same channel returns the original row.
+
+ALTER TABLE messages ADD COLUMN client_msg_id text;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_sender_channel_client_msg_id
+  ON messages (sender_id, channel_id, client_msg_id)
+  WHERE client_msg_id IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Resolved @mentions. <@user>, @channel and @here are expanded to one row
+-- per notified user when the message is sent, so "my mentions" and
+-- mention badges are plain index lookups instead of body scans.
+
+CREATE TABLE IF NOT EXISTS message_mentions (
+  message_id bigint NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind text NOT NULL CHECK (kind IN ('user', 'channel', 'here')),
+  PRIMARY KEY (message_id, user_id)
+);
+
+-- GET /users/me/mentions pages a user's mentions newest first.
+CREATE INDEX IF NOT EXISTS idx_message_mentions_user_id
+  ON message_mentions (user_id, message_id DESC);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS message_attachments;
+DROP TABLE IF EXISTS files;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS max_upload_bytes;
@@ -0,0 +1,24 @@
+-- Uploaded files and message attachments. File bytes live in the blob
+-- store under storage_key; this table holds the metadata and scoping.
+
+-- Per-tenant upload cap in bytes. NULL = server default (MAX_UPLOAD_BYTES).
+ALTER TABLE tenants ADD COLUMN max_upload_bytes bigint;
+
+CREATE TABLE IF NOT EXISTS files (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  tenant_id uuid NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  channel_id uuid NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+  uploader_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name text NOT NULL,
+  content_type text NOT NULL,  -- sniffed from the bytes, not client-supplied
+  size_bytes bigint NOT NULL,
+  storage_key text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS message_attachments (
+  message_id bigint NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+  file_id uuid NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+  position smallint NOT NULL,  -- display order within the message
+  PRIMARY KEY (message_id, file_id)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE files
+  DROP COLUMN IF EXISTS thumbnail_sizes,
+  DROP COLUMN IF EXISTS blurhash,
+  DROP COLUMN IF EXISTS height,
+  DROP COLUMN IF EXISTS width;
@@ -0,0 +1,9 @@
+-- Image metadata, filled in asynchronously after upload so clients can
+-- lay out and preview attachments without downloading the original.
+
+ALTER TABLE files
+  ADD COLUMN width integer,
+  ADD COLUMN height integer,
+  ADD COLUMN blurhash text,
+  -- Longest-edge sizes (px) with a thumbnail in the blob store, e.g. {64,360,720}.
+  ADD COLUMN thumbnail_sizes integer[] NOT NULL DEFAULT '{}';
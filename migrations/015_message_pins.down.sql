@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS message_pins;
+
+ALTER TABLE channels DROP COLUMN IF EXISTS pins_admin_only;
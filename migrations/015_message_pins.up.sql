@@ -0,0 +1,15 @@
+-- Pinned messages. A message is pinned at most once; the pin goes away
+-- when the message is soft-deleted (see MessageStore.SoftDelete).
+
+-- When true, only channel admins may pin and unpin.
+ALTER TABLE channels ADD COLUMN pins_admin_only boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS message_pins (
+  message_id bigint PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+  channel_id uuid NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+  pinned_by uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  pinned_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_pins_channel
+  ON message_pins (channel_id, pinned_at DESC);
@@ -0,0 +1,30 @@
+-- Messages queued for delivery at send_at. The scheduler claims due rows
+-- with FOR UPDATE SKIP LOCKED and a short lease (claimed_until), so any
+-- number of replicas can run it; a crashed claim is retried once the
+-- lease lapses. Delivery uses client_msg_id = 'scheduled:<id>', so a
+-- retry never posts twice.
+
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  tenant_id uuid NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  channel_id uuid NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+  sender_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  body text NOT NULL,
+  parent_id bigint,
+  attachment_ids uuid[] NOT NULL DEFAULT '{}',
+  send_at timestamptz NOT NULL,
+  status text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+  last_error text,           -- why delivery was rejected (status = 'failed')
+  message_id bigint,         -- the delivered message (status = 'sent')
+  claimed_until timestamptz, -- delivery lease
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- The scheduler's scan: due, pending rows in send_at order.
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_due
+  ON scheduled_messages (send_at) WHERE status = 'pending';
+
+-- A user's own queue.
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_sender
+  ON scheduled_messages (tenant_id, sender_id, send_at);
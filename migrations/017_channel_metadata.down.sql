@@ -0,0 +1,4 @@
+ALTER TABLE channels DROP COLUMN IF EXISTS created_by;
+ALTER TABLE channels DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE channels DROP COLUMN IF EXISTS purpose;
+ALTER TABLE channels DROP COLUMN IF EXISTS topic;
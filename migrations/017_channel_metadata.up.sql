@@ -0,0 +1,8 @@
+-- Channel metadata: free-text topic and purpose, who created the channel,
+-- and archiving. An archived channel stays readable but rejects new
+-- messages and joins until it is unarchived.
+
+ALTER TABLE channels ADD COLUMN topic text NOT NULL DEFAULT '';
+ALTER TABLE channels ADD COLUMN purpose text NOT NULL DEFAULT '';
+ALTER TABLE channels ADD COLUMN archived_at timestamptz;
+ALTER TABLE channels ADD COLUMN created_by uuid REFERENCES users(id) ON DELETE SET NULL;
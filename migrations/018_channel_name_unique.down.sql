@@ -0,0 +1,2 @@
+-- Names stay normalized; only the uniqueness guarantee is dropped.
+DROP INDEX IF EXISTS idx_channels_tenant_name;
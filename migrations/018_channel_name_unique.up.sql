@@ -0,0 +1,26 @@
+-- Channel names are normalized (lowercase; letters, digits, '-' and '_')
+-- and unique per tenant. DMs have no name and are excluded.
+
+-- Bring existing names into the allowed form: trim, drop a leading '#',
+-- lowercase, turn whitespace runs into '-', strip any other disallowed
+-- character (the API rejects those instead) and cut to 80 bytes. Names
+-- left empty become "channel".
+UPDATE channels
+SET name = COALESCE(NULLIF(
+      left(regexp_replace(regexp_replace(lower(trim(leading '#' from trim(name))), '\s+', '-', 'g'), '[^a-z0-9_-]', '', 'g'), 80),
+      ''), 'channel')
+WHERE kind = 'channel';
+
+-- Rename duplicates (all but the oldest) with a piece of their ID.
+UPDATE channels c
+SET name = left(c.name, 71) || '-' || left(c.id::text, 8)
+FROM (
+  SELECT id, row_number() OVER (PARTITION BY tenant_id, name ORDER BY created_at, id) AS n
+  FROM channels
+  WHERE kind = 'channel'
+) d
+WHERE c.id = d.id AND d.n > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channels_tenant_name
+  ON channels (tenant_id, name)
+  WHERE kind = 'channel';
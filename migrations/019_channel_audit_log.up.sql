@@ -0,0 +1,15 @@
+-- Audit trail for channel administration (privacy conversions and, later,
+-- membership changes). Append-only; rows go away with the channel.
+
+CREATE TABLE IF NOT EXISTS channel_audit_log (
+  id bigserial PRIMARY KEY,
+  tenant_id uuid NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  channel_id uuid NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+  actor_id uuid REFERENCES users(id) ON DELETE SET NULL,
+  action text NOT NULL,
+  details jsonb NOT NULL DEFAULT '{}',
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_audit_log_channel
+  ON channel_audit_log (channel_id, created_at DESC);
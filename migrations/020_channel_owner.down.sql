@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_channel_members_owner;
+
+UPDATE channel_members SET role = 'admin' WHERE role = 'owner';
@@ -0,0 +1,21 @@
+-- Channel ownership. Each regular channel gets at most one "owner", who
+-- outranks admins and can hand ownership to another member.
+
+-- Backfill: the creator if they're still a member in any role, else an
+-- admin. Memberships have no join time, so among admins the lowest user
+-- ID wins, just to make the choice deterministic. Channels with neither
+-- get no owner.
+UPDATE channel_members m
+SET role = 'owner'
+FROM (
+  SELECT DISTINCT ON (cm.channel_id) cm.channel_id, cm.user_id
+  FROM channel_members cm
+  JOIN channels c ON c.id = cm.channel_id
+  WHERE c.kind = 'channel' AND (cm.user_id = c.created_by OR cm.role = 'admin')
+  ORDER BY cm.channel_id, (cm.user_id = c.created_by) DESC NULLS LAST, cm.user_id
+) o
+WHERE m.channel_id = o.channel_id AND m.user_id = o.user_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channel_members_owner
+  ON channel_members (channel_id)
+  WHERE role = 'owner';
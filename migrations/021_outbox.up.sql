@@ -0,0 +1,25 @@
+-- Transactional outbox: real-time events written in the same transaction
+-- as the message they announce, then published to Redis by the relay.
+-- Rows are claimed with FOR UPDATE SKIP LOCKED and a lease (claimed_until)
+-- so every replica can run a relay; a failed publish is retried at
+-- next_attempt_at with backoff. Sent rows are pruned after a while.
+
+CREATE TABLE IF NOT EXISTS outbox (
+  id bigserial PRIMARY KEY,
+  topic text NOT NULL,        -- Redis pub/sub key, e.g. ch:<uuid> or user:<uuid>
+  payload bytea NOT NULL,     -- JSON event, published as-is
+  attempts int NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  claimed_until timestamptz,  -- relay lease
+  last_error text,
+  sent_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- The relay's scan: unsent rows whose next attempt is due.
+CREATE INDEX IF NOT EXISTS idx_outbox_pending
+  ON outbox (next_attempt_at, id) WHERE sent_at IS NULL;
+
+-- Pruning published rows.
+CREATE INDEX IF NOT EXISTS idx_outbox_sent
+  ON outbox (sent_at) WHERE sent_at IS NOT NULL;
@@ -0,0 +1,19 @@
+-- Files whose only message was deleted. They are no longer served or
+-- attachable; the blob itself is left in the store.
+ALTER TABLE files ADD COLUMN deleted_at timestamptz;
+
+-- Tombstones created before this migration kept their attachments.
+WITH detached AS (
+  DELETE FROM message_attachments ma
+  USING messages m
+  WHERE m.id = ma.message_id AND m.deleted_at IS NOT NULL
+  RETURNING ma.file_id
+)
+UPDATE files f
+SET deleted_at = now()
+WHERE f.id IN (SELECT file_id FROM detached)
+  AND NOT EXISTS (
+    SELECT 1 FROM message_attachments ma
+    JOIN messages m ON m.id = ma.message_id
+    WHERE ma.file_id = f.id AND m.deleted_at IS NULL
+  );
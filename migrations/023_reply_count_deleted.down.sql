@@ -0,0 +1 @@
+-- Data-only migration: the recounted values are correct either way.
@@ -0,0 +1,15 @@
+-- Deleting a reply used to leave its parent's counters alone. Recount
+-- every thread parent from the replies that are still standing.
+UPDATE messages p
+SET reply_count = r.n,
+    last_reply_at = r.last_at
+FROM (
+  SELECT parent_id,
+         count(*) FILTER (WHERE deleted_at IS NULL) AS n,
+         max(created_at) FILTER (WHERE deleted_at IS NULL) AS last_at
+  FROM messages
+  WHERE parent_id IS NOT NULL
+  GROUP BY parent_id
+) r
+WHERE p.id = r.parent_id
+  AND (p.reply_count <> r.n OR p.last_reply_at IS DISTINCT FROM r.last_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_scheduled_messages_sender_channel_client_msg_id;
+
+ALTER TABLE scheduled_messages DROP COLUMN IF EXISTS client_msg_id;
@@ -0,0 +1,9 @@
+-- Client idempotency key for scheduling. A retried POST with send_at and
+-- the same key from the same sender in the same channel returns the
+-- original scheduled message instead of queueing a second one.
+
+ALTER TABLE scheduled_messages ADD COLUMN client_msg_id text;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scheduled_messages_sender_channel_client_msg_id
+  ON scheduled_messages (sender_id, channel_id, client_msg_id)
+  WHERE client_msg_id IS NOT NULL;